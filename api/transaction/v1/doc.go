@@ -0,0 +1,5 @@
+// Package transactionv1 holds the gRPC API of the transactions, generated
+// from transaction.proto with `go generate`.
+package transactionv1
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/transaction/v1/transaction.proto
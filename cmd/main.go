@@ -5,9 +5,14 @@ import (
 	_ "embed"
 	"github.com/yurikilian/bills/internal/logger"
 	"github.com/yurikilian/bills/internal/transaction"
+	"github.com/yurikilian/bills/pkg/admin"
+	"github.com/yurikilian/bills/pkg/app"
 	"github.com/yurikilian/bills/pkg/db"
+	"github.com/yurikilian/bills/pkg/grpcserver"
+	"github.com/yurikilian/bills/pkg/health"
 	"github.com/yurikilian/bills/pkg/middleware"
 	"github.com/yurikilian/bills/pkg/server"
+	"github.com/yurikilian/bills/pkg/telemetry"
 	"time"
 )
 
@@ -17,29 +22,51 @@ func main() {
 
 	configurationProvider := server.NewConfigurationProvider()
 	dbConnection, closeDb := db.ConnectPgsql(ctx, configurationProvider.GetDBConnectionString())
-	defer closeDb()
+	shutdownTelemetry := telemetry.InitWithShutdown(ctx)
 
-	/*closeTelemetry := telemetry.Init(ctx)
-	defer closeTelemetry()*/
+	application := app.New(&app.Resources{Log: logger.Log, DB: dbConnection, Config: configurationProvider}).
+		Mount("/transactions", transaction.NewTransactionModuleBuilder())
 
-	transactionModuleProvider := transaction.
-		NewTransactionModuleBuilder().
-		WithPsqlStorage(dbConnection).
-		Build()
+	healthRegistry := health.NewRegistry().
+		Readiness(health.PostgresCheck(dbConnection).WithCache(5 * time.Second)).
+		Readiness(health.DiskSpaceCheck("/", 100<<20).NonCritical())
+
+	router := server.NewRestRouter()
+
+	adminAddress := "127.0.0.1:3501"
+	adminSrv := admin.NewServer(adminAddress, &admin.Options{
+		Log:    logger.Log,
+		Router: router,
+		Config: configurationProvider.Effective,
+	})
 
 	srvCtx := context.WithValue(ctx, "startup_time", time.Now().UnixNano())
-	problem, ok := server.NewRestServer(server.NewRestServerOptions(":3500", logger.Log)).
+	options := server.NewRestServerOptions(":3500", logger.Log).
+		WithLimits(configurationProvider.GetServerLimits()).
+		WithUpgrade(server.NewUpgradeOptions())
+
+	// Shutdown hooks run in reverse order, telemetry is flushed last so the
+	// spans of the shutdown itself are exported.
+	srv := server.NewRestServer(options).
+		OnShutdown(shutdownTelemetry).
+		OnShutdown(func(ctx context.Context) error {
+			closeDb()
+			return nil
+		}).
+		AttachListener(server.ListenerOptions{Network: "tcp", Address: adminAddress}, adminSrv.Serve, adminSrv.Shutdown).
 		Use(middleware.Otel()).
 		Use(middleware.Json()).
-		Router(
-			server.NewRestRouter().
-				Get("/", transactionModuleProvider.ProvideRoute().Find).
-				POST("/", transactionModuleProvider.ProvideRoute().Create),
-		).
-		Start(srvCtx)
-
-	if !ok {
-		logger.Log.Fatal(context.Background(), problem.Error())
+		Router(router)
+
+	if _, err := application.Attach(srv); err != nil {
+		logger.Log.Fatal(ctx, err.Error())
 	}
 
+	grpcSrv := grpcserver.NewGrpcServer(grpcserver.NewGrpcServerOptions(":3502", logger.Log))
+	application.RegisterGrpc(grpcSrv)
+	grpcSrv.Attach(srv)
+
+	if err := healthRegistry.Mount(srv).Run(srvCtx); err != nil {
+		logger.Log.Fatal(context.Background(), err.Error())
+	}
 }
@@ -0,0 +1,90 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	transactionv1 "github.com/yurikilian/bills/api/transaction/v1"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// GrpcService exposes the Service through gRPC. Errors are problems, converted
+// to statuses by the grpcserver interceptors.
+type GrpcService struct {
+	transactionv1.UnimplementedTransactionServiceServer
+	service *Service
+}
+
+func NewGrpcService(service *Service) *GrpcService {
+	return &GrpcService{service: service}
+}
+
+func (g *GrpcService) CreateTransaction(ctx context.Context, req *transactionv1.CreateTransactionRequest) (*transactionv1.CreateTransactionResponse, error) {
+	request := fromCreateMessage(req)
+	if err := server.Validator.Validate(request); err != nil {
+		return nil, exception.NewValidationProblem(server.Validator.MapValidationProblems(err))
+	}
+
+	entity, err := g.service.Create(ctx, request)
+	if err != nil {
+		return nil, exception.NewInternalServerError(err.Error())
+	}
+	return &transactionv1.CreateTransactionResponse{Transaction: toMessage(entity)}, nil
+}
+
+func (g *GrpcService) GetTransaction(ctx context.Context, req *transactionv1.GetTransactionRequest) (*transactionv1.GetTransactionResponse, error) {
+	entity, err := g.service.Find(ctx, req.GetId())
+	if err != nil {
+		return nil, exception.NewInternalServerError(err.Error())
+	}
+	if entity == nil {
+		return nil, exception.NewNotFoundProblem(fmt.Sprintf("Transaction %v not found", req.GetId()))
+	}
+	return &transactionv1.GetTransactionResponse{Transaction: toMessage(entity)}, nil
+}
+
+// ListTransactions pages with an opaque token, which is the offset of the
+// next page.
+func (g *GrpcService) ListTransactions(ctx context.Context, req *transactionv1.ListTransactionsRequest) (*transactionv1.ListTransactionsResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, exception.NewBadRequestProblem("page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	offset := 0
+	if token := req.GetPageToken(); len(token) > 0 {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 {
+			return nil, exception.NewBadRequestProblem("page_token is invalid")
+		}
+	}
+
+	// One more entity tells whether there is a next page.
+	entities, err := g.service.List(ctx, offset, pageSize+1)
+	if err != nil {
+		return nil, exception.NewInternalServerError(err.Error())
+	}
+
+	response := &transactionv1.ListTransactionsResponse{}
+	if len(entities) > pageSize {
+		entities = entities[:pageSize]
+		response.NextPageToken = strconv.Itoa(offset + pageSize)
+	}
+	for _, entity := range entities {
+		response.Transactions = append(response.Transactions, toMessage(entity))
+	}
+	return response, nil
+}
+
+var _ transactionv1.TransactionServiceServer = (*GrpcService)(nil)
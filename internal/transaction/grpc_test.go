@@ -0,0 +1,138 @@
+package transaction
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	transactionv1 "github.com/yurikilian/bills/api/transaction/v1"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/app"
+	"github.com/yurikilian/bills/pkg/grpcserver"
+	"github.com/yurikilian/bills/pkg/server"
+	"github.com/yurikilian/bills/pkg/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+func newGrpcClient(t *testing.T) transactionv1.TransactionServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	log := logger.NewProvider().ProvideLog()
+
+	application := app.New(&app.Resources{Log: log}).
+		Mount("/transactions", NewTransactionModuleBuilder().WithInMemoryStorage(storage.NewInMemoryStorage[Entity]()))
+	require.NoError(t, application.Init(server.NewRestRouter()))
+
+	srv := grpcserver.NewGrpcServer(grpcserver.NewGrpcServerOptions("", log).WithListener(listener))
+	application.RegisterGrpc(srv)
+	require.NoError(t, srv.Serve(context.Background()))
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return transactionv1.NewTransactionServiceClient(conn)
+}
+
+func Test_Transaction_Grpc(t *testing.T) {
+	client := newGrpcClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateTransaction(ctx, &transactionv1.CreateTransactionRequest{
+		Title:       "Supermarket",
+		Description: "Mensal shop",
+		Price:       53.25,
+		Currency:    "EUR",
+		Type:        "CREDIT",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, created.Transaction.Id)
+
+	found, err := client.GetTransaction(ctx, &transactionv1.GetTransactionRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Supermarket", found.Transaction.Title)
+
+	_, err = client.GetTransaction(ctx, &transactionv1.GetTransactionRequest{Id: 42})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateTransaction(ctx, &transactionv1.CreateTransactionRequest{Title: "Supermarket", Currency: "DDD"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	fieldErrors := make([]string, 0)
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fieldErrors = append(fieldErrors, violation.Description)
+			}
+		}
+	}
+	assert.Contains(t, fieldErrors, "Currency value must be one of the following: EUR")
+}
+
+func Test_Transaction_Grpc_List(t *testing.T) {
+	client := newGrpcClient(t)
+	ctx := context.Background()
+
+	for _, title := range []string{"Rent", "Supermarket", "Gym"} {
+		_, err := client.CreateTransaction(ctx, &transactionv1.CreateTransactionRequest{
+			Title: title, Description: title, Price: 10, Currency: "EUR", Type: "DEBIT",
+		})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name              string
+		request           *transactionv1.ListTransactionsRequest
+		wantTitles        []string
+		wantNextPageToken string
+		wantCode          codes.Code
+	}{
+		{
+			name:       "Should list every transaction given the default page size",
+			request:    &transactionv1.ListTransactionsRequest{},
+			wantTitles: []string{"Rent", "Supermarket", "Gym"},
+		},
+		{
+			name:              "Should return a next page token given more transactions than the page size",
+			request:           &transactionv1.ListTransactionsRequest{PageSize: 2},
+			wantTitles:        []string{"Rent", "Supermarket"},
+			wantNextPageToken: "2",
+		},
+		{
+			name:       "Should continue from the page token",
+			request:    &transactionv1.ListTransactionsRequest{PageSize: 2, PageToken: "2"},
+			wantTitles: []string{"Gym"},
+		},
+		{
+			name:     "Should reject invalid page tokens",
+			request:  &transactionv1.ListTransactionsRequest{PageToken: "next"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.ListTransactions(ctx, tt.request)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if err != nil {
+				return
+			}
+
+			titles := make([]string, 0)
+			for _, transaction := range resp.Transactions {
+				titles = append(titles, transaction.Title)
+			}
+			assert.Equal(t, tt.wantTitles, titles)
+			assert.Equal(t, tt.wantNextPageToken, resp.NextPageToken)
+		})
+	}
+}
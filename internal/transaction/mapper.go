@@ -1 +1,24 @@
 package transaction
+
+import transactionv1 "github.com/yurikilian/bills/api/transaction/v1"
+
+func toMessage(entity *Entity) *transactionv1.Transaction {
+	return &transactionv1.Transaction{
+		Id:          entity.Id,
+		Title:       entity.Title,
+		Description: entity.Description,
+		Price:       entity.Price,
+		Currency:    entity.Currency,
+		Type:        entity.Type,
+	}
+}
+
+func fromCreateMessage(req *transactionv1.CreateTransactionRequest) CreationRequest {
+	return CreationRequest{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Price:       req.GetPrice(),
+		Currency:    req.GetCurrency(),
+		Type:        req.GetType(),
+	}
+}
@@ -8,6 +8,17 @@ type CreationRequest struct {
 	Type        string  `json:"type" validate:"required,oneof=CREDIT DEBIT"`
 }
 
+// FindRequest is the deprecated JSON body of a find, the id query parameter
+// replaces it.
 type FindRequest struct {
-	id float64 `param:"id"`
+	Id float64 `json:"id"`
+}
+
+type GetParams struct {
+	Id float64 `json:"id" validate:"required"`
+}
+
+type ListParams struct {
+	Offset int `json:"offset" validate:"gte=0"`
+	Limit  int `json:"limit" validate:"gte=0,lte=500"`
 }
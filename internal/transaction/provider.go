@@ -1,57 +1,175 @@
 package transaction
 
 import (
+	"context"
 	"database/sql"
+	transactionv1 "github.com/yurikilian/bills/api/transaction/v1"
+	"github.com/yurikilian/bills/pkg/app"
+	"github.com/yurikilian/bills/pkg/di"
+	"github.com/yurikilian/bills/pkg/jsonrpc"
+	"github.com/yurikilian/bills/pkg/server"
 	"github.com/yurikilian/bills/pkg/storage"
+	"google.golang.org/grpc"
 )
 
+// Provide registers the transaction repository, service, route, gRPC service
+// and JSON-RPC endpoint. They resolve the storage.Storage[Entity] registered
+// by the caller.
+func Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(s *di.Scope) (IRepository, error) {
+		entityStorage, err := di.Resolve[storage.Storage[Entity]](s)
+		if err != nil {
+			return nil, err
+		}
+		return NewRepository(entityStorage), nil
+	})
+
+	di.Provide(c, di.Singleton, func(s *di.Scope) (*Service, error) {
+		repository, err := di.Resolve[IRepository](s)
+		if err != nil {
+			return nil, err
+		}
+		return NewTransactionService(repository), nil
+	})
+
+	di.Provide(c, di.Singleton, func(s *di.Scope) (*Route, error) {
+		service, err := di.Resolve[*Service](s)
+		if err != nil {
+			return nil, err
+		}
+		return &Route{service: service}, nil
+	})
+
+	di.Provide(c, di.Singleton, func(s *di.Scope) (*GrpcService, error) {
+		service, err := di.Resolve[*Service](s)
+		if err != nil {
+			return nil, err
+		}
+		return NewGrpcService(service), nil
+	})
+
+	di.Provide(c, di.Singleton, func(s *di.Scope) (*jsonrpc.Endpoint, error) {
+		service, err := di.Resolve[*Service](s)
+		if err != nil {
+			return nil, err
+		}
+		return NewRpcEndpoint(service), nil
+	})
+}
+
 type ModuleProvider struct {
-	storage storage.Storage[Entity]
-	route   *Route
+	route       *Route
+	grpcService *GrpcService
+	rpcEndpoint *jsonrpc.Endpoint
 }
 
 func (p *ModuleProvider) ProvideRoute() *Route {
-	if p.route != nil {
-		return p.route
-	}
-
-	p.route = &Route{service: NewTransactionService(NewRepository(p.storage))}
 	return p.route
 }
 
+func (p *ModuleProvider) ProvideGrpcService() *GrpcService {
+	return p.grpcService
+}
+
+func (p *ModuleProvider) ProvideRpcEndpoint() *jsonrpc.Endpoint {
+	return p.rpcEndpoint
+}
+
+// ModuleBuilder builds the transaction module. It is also the app.Module of
+// the transaction API, using the shared database unless a storage was set.
 type ModuleBuilder struct {
-	provider *ModuleProvider
+	app.NopLifecycle
+	container  *di.Container
+	hasStorage bool
+	provider   *ModuleProvider
 }
 
 func NewTransactionModuleBuilder() *ModuleBuilder {
-	return &ModuleBuilder{
-		provider: &ModuleProvider{},
-	}
+	container := di.New()
+	Provide(container)
+
+	return &ModuleBuilder{container: container}
 }
 
 func (p *ModuleBuilder) WithPsqlStorage(dbConnection *sql.DB) *ModuleBuilder {
-
-	if p.provider.storage != nil {
-		panic("Storage already defined")
-	}
-
 	tableName := "transaction"
-	p.provider.storage = storage.GetPsql[Entity](dbConnection, &tableName)
+	di.Value(p.container, storage.GetPsql[Entity](dbConnection, &tableName))
+	di.Bind[storage.Storage[Entity], *storage.PsqlStorage[Entity]](p.container)
+	p.hasStorage = true
 	return p
 }
 
 func (p *ModuleBuilder) WithInMemoryStorage(inMemory *storage.InMemoryStorage[Entity]) *ModuleBuilder {
-	if p.provider.storage != nil {
-		panic("Storage already defined")
-	}
-	p.provider.storage = inMemory
+	di.Value(p.container, inMemory)
+	di.Bind[storage.Storage[Entity], *storage.InMemoryStorage[Entity]](p.container)
+	p.hasStorage = true
 	return p
 }
 
-func (p *ModuleBuilder) Build() *ModuleProvider {
-	if p.provider.storage == nil {
-		panic("the transaction storage is not defined")
+// Build validates the dependencies, such as a missing or twice defined
+// storage, and resolves the module route.
+func (p *ModuleBuilder) Build() (*ModuleProvider, error) {
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	if err := p.container.Validate(); err != nil {
+		return nil, err
+	}
+
+	route, err := di.Resolve[*Route](p.container.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	grpcService, err := di.Resolve[*GrpcService](p.container.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	rpcEndpoint, err := di.Resolve[*jsonrpc.Endpoint](p.container.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	p.provider = &ModuleProvider{route: route, grpcService: grpcService, rpcEndpoint: rpcEndpoint}
+	return p.provider, nil
+}
+
+func (p *ModuleBuilder) Name() string {
+	return "transaction"
+}
+
+func (p *ModuleBuilder) Dependencies() []string {
+	return nil
+}
+
+func (p *ModuleBuilder) Init(resources *app.Resources) error {
+	if !p.hasStorage && resources.DB != nil {
+		p.WithPsqlStorage(resources.DB)
 	}
 
-	return p.provider
+	_, err := p.Build()
+	return err
+}
+
+// Stop closes the singletons created by the module container.
+func (p *ModuleBuilder) Stop(context.Context) error {
+	return p.container.Close()
+}
+
+func (p *ModuleBuilder) Register(router *server.RestRouter) {
+	route := p.provider.ProvideRoute()
+	router.
+		Get("/", route.Find).
+		POST("/", route.Create)
+
+	p.provider.ProvideRpcEndpoint().Mount(router, "/rpc")
 }
+
+func (p *ModuleBuilder) RegisterGrpc(registrar grpc.ServiceRegistrar) {
+	transactionv1.RegisterTransactionServiceServer(registrar, p.provider.ProvideGrpcService())
+}
+
+var _ app.Module = (*ModuleBuilder)(nil)
+var _ app.GrpcModule = (*ModuleBuilder)(nil)
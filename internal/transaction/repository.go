@@ -1,24 +1,30 @@
 package transaction
 
 import (
+	"context"
 	"github.com/yurikilian/bills/pkg/storage"
 )
 
 type IRepository interface {
-	Create(transaction *Entity) (*Entity, error)
-	Find(transactionId float64) (*Entity, error)
+	Create(ctx context.Context, transaction *Entity) (*Entity, error)
+	Find(ctx context.Context, transactionId float64) (*Entity, error)
+	List(ctx context.Context, offset int, limit int) ([]*Entity, error)
 }
 
 type Repository struct {
 	storage storage.Storage[Entity]
 }
 
-func (r *Repository) Find(transactionId float64) (*Entity, error) {
-	return r.storage.Find(transactionId)
+func (r *Repository) Find(ctx context.Context, transactionId float64) (*Entity, error) {
+	return r.storage.Find(ctx, transactionId)
 }
 
-func (r *Repository) Create(transaction *Entity) (*Entity, error) {
-	return r.storage.Create(transaction)
+func (r *Repository) Create(ctx context.Context, transaction *Entity) (*Entity, error) {
+	return r.storage.Create(ctx, transaction)
+}
+
+func (r *Repository) List(ctx context.Context, offset int, limit int) ([]*Entity, error) {
+	return r.storage.List(ctx, offset, limit)
 }
 
 func NewRepository(storage storage.Storage[Entity]) IRepository {
@@ -3,6 +3,7 @@ package transaction
 import (
 	"github.com/yurikilian/bills/pkg/exception"
 	"github.com/yurikilian/bills/pkg/server"
+	"strconv"
 )
 
 type Route struct {
@@ -14,11 +15,20 @@ func (r *Route) Find(ctx server.IHttpContext) error {
 	ctx.Logger().Debug(ctx.ReqCtx(), "Entering find function")
 
 	var request FindRequest
-	if bErr := ctx.ReadBody(&request); bErr != nil {
+	if query := ctx.Request().URL.Query(); query.Has("id") {
+		// Prefer the query parameter, GET bodies are dropped by proxies and
+		// ignored by the response cache.
+		id, pErr := strconv.ParseFloat(query.Get("id"), 64)
+		if pErr != nil {
+			return exception.NewBadRequestProblem("The id query parameter must be a number")
+		}
+		request.Id = id
+	} else if bErr := ctx.ReadBody(&request); bErr != nil {
+		// Deprecated: callers sending the id in a JSON body keep working.
 		return bErr
 	}
 
-	trn, err := r.service.Find(request.id)
+	trn, err := r.service.Find(ctx.ReqCtx(), request.Id)
 	if err != nil {
 		ctx.Logger().Error(ctx.ReqCtx(), err.Error())
 		return exception.NewInternalServerError(err.Error())
@@ -32,7 +42,7 @@ func (r *Route) Create(ctx server.IHttpContext) error {
 		return bErr
 	}
 
-	if err := r.service.Create(request); err != nil {
+	if _, err := r.service.Create(ctx.ReqCtx(), request); err != nil {
 		ctx.Logger().Debug(ctx.ReqCtx(), err.Error())
 		return exception.NewInternalServerError(err.Error())
 	}
@@ -1,17 +1,16 @@
 package transaction
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/app"
 	"github.com/yurikilian/bills/pkg/exception"
 	"github.com/yurikilian/bills/pkg/middleware"
 	"github.com/yurikilian/bills/pkg/server"
+	"github.com/yurikilian/bills/pkg/server/servertest"
 	"github.com/yurikilian/bills/pkg/storage"
 	"net/http"
-	"net/http/httptest"
 	"testing"
 )
 
@@ -117,7 +116,8 @@ func Test_Transaction_Create(t *testing.T) {
 	}
 
 	inMemoryDb := storage.NewInMemoryStorage[Entity]()
-	moduleProvider := NewTransactionModuleBuilder().WithInMemoryStorage(inMemoryDb).Build()
+	moduleProvider, err := NewTransactionModuleBuilder().WithInMemoryStorage(inMemoryDb).Build()
+	assert.NoError(t, err)
 
 	router := server.NewRestRouter().
 		Get("/transactions", moduleProvider.ProvideRoute().Find).
@@ -128,43 +128,129 @@ func Test_Transaction_Create(t *testing.T) {
 		Use(middleware.Otel()).
 		Use(middleware.Json())
 
-	defer func() {
-		_, _ = restServer.Start(nil)
-	}()
+	client := servertest.New(t, restServer)
 
 	for _, test := range tests {
 
 		t.Run(test.name, func(t *testing.T) {
-			jsonReq, err := json.Marshal(test.request)
-			assert.NoError(t, err)
-
-			req := httptest.NewRequest(http.MethodPost, "/transactions", bytes.NewReader(jsonReq))
-			req.Header.Set("Content-Type", "application/json")
-
-			rec := httptest.NewRecorder()
-
-			restServer.ServeHTTP(rec, req)
-
-			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			res := client.Post("/transactions").WithJSON(test.request).Do()
 
 			if test.expectsErr {
-				var problem exception.Problem
-				err := json.Unmarshal(rec.Body.Bytes(), &problem)
-				if err != nil {
-					assert.NoError(t, err)
-				}
-
-				exception.AssertProblem(t, test.exceptedEx, problem)
+				res.AssertProblem(test.exceptedEx)
+			} else {
+				res.AssertStatus(test.expectedStatusCode)
 			}
 
 			if test.expectedSavedEntity != nil {
-				saved, err := inMemoryDb.Find(test.expectedSavedEntity.Id)
+				saved, err := inMemoryDb.Find(context.Background(), test.expectedSavedEntity.Id)
 				assert.NoError(t, err)
 				assert.NotNil(t, saved)
 			}
 
 		})
 	}
+}
+
+func Test_Transaction_Find(t *testing.T) {
+	restServer := server.NewRestServer(server.NewRestServerOptions(":3050", logger.NewProvider().ProvideLog())).
+		Use(middleware.Json())
+
+	inMemoryDb := storage.NewInMemoryStorage[Entity]()
+	_, err := inMemoryDb.Create(context.Background(), &Entity{Title: "Supermarket", Description: "Mensal shop", Price: 53.25, Currency: "EUR", Type: "CREDIT"})
+	assert.NoError(t, err)
+
+	module := NewTransactionModuleBuilder().WithInMemoryStorage(inMemoryDb)
+	_, err = app.New(nil).Mount("/transactions", module).Attach(restServer)
+	assert.NoError(t, err)
+
+	client := servertest.New(t, restServer)
+
+	tests := []struct {
+		name           string
+		path           string
+		body           interface{}
+		expectedStatus int
+		expectedJSON   map[string]interface{}
+	}{
+		{
+			name:           "Should return the transaction given its id in the query",
+			path:           "/transactions?id=1",
+			expectedStatus: http.StatusOK,
+			expectedJSON:   map[string]interface{}{"Id": 1.0, "Title": "Supermarket"},
+		},
+		{
+			name:           "Should return null given an unknown id",
+			path:           "/transactions?id=42",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Should return 400 bad request given a non numeric id",
+			path:           "/transactions?id=one",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return the transaction given its id in a json body",
+			path:           "/transactions",
+			body:           map[string]float64{"id": 1},
+			expectedStatus: http.StatusOK,
+			expectedJSON:   map[string]interface{}{"Id": 1.0, "Title": "Supermarket"},
+		},
+		{
+			name:           "Should prefer the query given an id in both",
+			path:           "/transactions?id=1",
+			body:           map[string]float64{"id": 42},
+			expectedStatus: http.StatusOK,
+			expectedJSON:   map[string]interface{}{"Id": 1.0},
+		},
+		{
+			name:           "Should return null given no id nor content type",
+			path:           "/transactions",
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := client.Get(tt.path)
+			if tt.body != nil {
+				req = req.WithJSON(tt.body)
+			}
+			res := req.Do().AssertStatus(tt.expectedStatus)
+
+			for path, want := range tt.expectedJSON {
+				res.AssertJSONPath(path, want)
+			}
+		})
+	}
+}
+
+func Test_Transaction_Rpc(t *testing.T) {
+	restServer := server.NewRestServer(server.NewRestServerOptions(":3050", logger.NewProvider().ProvideLog())).
+		Use(middleware.Json())
+
+	module := NewTransactionModuleBuilder().WithInMemoryStorage(storage.NewInMemoryStorage[Entity]())
+	_, err := app.New(nil).Mount("/transactions", module).Attach(restServer)
+	assert.NoError(t, err)
+
+	client := servertest.New(t, restServer)
+
+	client.Post("/transactions/rpc").
+		WithJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "transaction.create",
+			"params":  CreationRequest{Title: "Rent", Description: "Flat", Price: 700, Currency: "EUR", Type: "DEBIT"},
+			"id":      1,
+		}).
+		Do().
+		AssertStatus(http.StatusOK).
+		AssertJSONPath("result.Id", 1.0)
 
-	restServer.Shutdown(context.Background())
+	client.Post("/transactions/rpc").
+		WithBody("application/json", []byte(`[
+			{"jsonrpc":"2.0","method":"transaction.get","params":{"id":1},"id":1},
+			{"jsonrpc":"2.0","method":"transaction.create","params":{"title":"Rent"},"id":2}
+		]`)).
+		Do().
+		AssertStatus(http.StatusOK).
+		AssertJSONPath("0.result.Title", "Rent").
+		AssertJSONPath("1.error.code", -32602.0)
 }
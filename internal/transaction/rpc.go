@@ -0,0 +1,36 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/jsonrpc"
+)
+
+// NewRpcEndpoint exposes the Service as the transaction.create,
+// transaction.get and transaction.list JSON-RPC methods.
+func NewRpcEndpoint(service *Service) *jsonrpc.Endpoint {
+	endpoint := jsonrpc.NewEndpoint(jsonrpc.NewOptions())
+
+	jsonrpc.Register(endpoint, "transaction.create", service.Create)
+
+	jsonrpc.Register(endpoint, "transaction.get", func(ctx context.Context, params GetParams) (*Entity, error) {
+		entity, err := service.Find(ctx, params.Id)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return nil, exception.NewNotFoundProblem(fmt.Sprintf("Transaction %v not found", params.Id))
+		}
+		return entity, nil
+	})
+
+	jsonrpc.Register(endpoint, "transaction.list", func(ctx context.Context, params ListParams) ([]*Entity, error) {
+		if params.Limit == 0 {
+			params.Limit = defaultPageSize
+		}
+		return service.List(ctx, params.Offset, params.Limit)
+	})
+
+	return endpoint
+}
@@ -1,10 +1,12 @@
 package transaction
 
+import "context"
+
 type Service struct {
 	repository IRepository
 }
 
-func (s *Service) Create(req CreationRequest) error {
+func (s *Service) Create(ctx context.Context, req CreationRequest) (*Entity, error) {
 	entity := &Entity{
 		Title:       req.Title,
 		Description: req.Description,
@@ -12,20 +14,22 @@ func (s *Service) Create(req CreationRequest) error {
 		Type:        req.Type,
 		Price:       req.Price,
 	}
-	if _, err := s.repository.Create(entity); err != nil {
-		return err
-	}
-	return nil
+	return s.repository.Create(ctx, entity)
 }
 
-func (s *Service) Find(id float64) (*Entity, error) {
-	trn, err := s.repository.Find(id)
+func (s *Service) Find(ctx context.Context, id float64) (*Entity, error) {
+	trn, err := s.repository.Find(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return trn, nil
 }
 
+// List returns a page of transactions ordered by id.
+func (s *Service) List(ctx context.Context, offset int, limit int) ([]*Entity, error) {
+	return s.repository.List(ctx, offset, limit)
+}
+
 func NewTransactionService(repository IRepository) *Service {
 	return &Service{
 		repository: repository,
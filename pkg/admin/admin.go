@@ -0,0 +1,187 @@
+package admin
+
+import (
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"strings"
+)
+
+// Options configures the admin endpoints. The admin server exposes internals
+// and must only be bound to a private interface.
+type Options struct {
+	Log logger.Logger `validate:"required"`
+	// Router is the public router whose route table is exposed.
+	Router *server.RestRouter
+	// Config returns the effective configuration, with secrets already redacted.
+	Config func() map[string]interface{}
+}
+
+type BuildInfo struct {
+	GoVersion string            `json:"goVersion"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings"`
+	Deps      map[string]string `json:"deps"`
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level" validate:"required,oneof=TRACE DEBUG INFO WARN ERROR FATAL trace debug info warn error fatal"`
+}
+
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// NewRouter registers the admin endpoints:
+//
+//	GET /debug/pprof/:profile  pprof index and profiles
+//	GET /debug/goroutines      full goroutine dump
+//	GET /routes                route table of the public router
+//	GET /config                effective configuration
+//	GET /buildinfo             module and VCS information of the binary
+//	GET|POST /loglevel         current log level, and switch it at runtime
+func NewRouter(options *Options) *server.RestRouter {
+	a := &admin{options: options}
+
+	return server.NewRestRouter().
+		Get("/debug/pprof/:profile", a.pprof).
+		POST("/debug/pprof/:profile", a.pprof).
+		Get("/debug/goroutines", a.goroutines).
+		Get("/routes", a.routes).
+		Get("/config", a.config).
+		Get("/buildinfo", a.buildInfo).
+		Get("/loglevel", a.logLevel).
+		POST("/loglevel", a.setLogLevel)
+}
+
+// NewServer returns a server for the admin endpoints on its own address, so
+// they are never reachable through the public listeners.
+func NewServer(bindAddress string, options *Options) *server.RestServer {
+	return server.NewRestServer(server.NewRestServerOptions(bindAddress, options.Log)).
+		Router(NewRouter(options))
+}
+
+type admin struct {
+	options *Options
+}
+
+func (a *admin) pprof(ctx server.IHttpContext) error {
+	w, r := ctx.Writer(), ctx.Request()
+
+	switch name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/"); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		if rpprof.Lookup(name) == nil {
+			return exception.NewRouteNotFound(r.URL.Path)
+		}
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+	return nil
+}
+
+func (a *admin) goroutines(ctx server.IHttpContext) error {
+	ctx.Writer().Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.Writer().WriteHeader(http.StatusOK)
+
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			_, err := ctx.Writer().Write(buf[:n])
+			return err
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func (a *admin) routes(ctx server.IHttpContext) error {
+	routes := make([]server.Route, 0)
+	if a.options.Router != nil {
+		routes = a.options.Router.Routes()
+	}
+	return ctx.WriteResponse(http.StatusOK, routes)
+}
+
+func (a *admin) config(ctx server.IHttpContext) error {
+	config := map[string]interface{}{}
+	if a.options.Config != nil {
+		config = a.options.Config()
+	}
+	return ctx.WriteResponse(http.StatusOK, config)
+}
+
+func (a *admin) buildInfo(ctx server.IHttpContext) error {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return exception.NewInternalServerError("build information is not available")
+	}
+
+	buildInfo := BuildInfo{
+		GoVersion: info.GoVersion,
+		Path:      info.Path,
+		Version:   info.Main.Version,
+		Settings:  map[string]string{},
+		Deps:      map[string]string{},
+	}
+	for _, setting := range info.Settings {
+		buildInfo.Settings[setting.Key] = setting.Value
+	}
+	for _, dep := range info.Deps {
+		buildInfo.Deps[dep.Path] = dep.Version
+	}
+	return ctx.WriteResponse(http.StatusOK, buildInfo)
+}
+
+func (a *admin) logLevel(ctx server.IHttpContext) error {
+	switcher, err := a.levelSwitcher()
+	if err != nil {
+		return err
+	}
+	return ctx.WriteResponse(http.StatusOK, LogLevelResponse{Level: logger.LevelLabelMap[switcher.Level()]})
+}
+
+func (a *admin) setLogLevel(ctx server.IHttpContext) error {
+	switcher, err := a.levelSwitcher()
+	if err != nil {
+		return err
+	}
+
+	var request LogLevelRequest
+	if bErr := ctx.ReadBody(&request); bErr != nil {
+		return bErr
+	}
+
+	level, err := logger.ParseLevel(request.Level)
+	if err != nil {
+		return exception.NewUnprocessableEntityProblem(err.Error())
+	}
+
+	previous := switcher.Level()
+	switcher.SetLevel(level)
+	a.options.Log.Warn(ctx.ReqCtx(), "Log level changed from "+logger.LevelLabelMap[previous]+" to "+logger.LevelLabelMap[level])
+
+	return ctx.WriteResponse(http.StatusOK, LogLevelResponse{Level: logger.LevelLabelMap[level]})
+}
+
+func (a *admin) levelSwitcher() (logger.LevelSwitcher, error) {
+	switcher, ok := a.options.Log.(logger.LevelSwitcher)
+	if !ok {
+		return nil, exception.NewConflictProblem("the logger does not support changing its level")
+	}
+	return switcher, nil
+}
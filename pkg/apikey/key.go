@@ -0,0 +1,91 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	keyPrefix      = "bk_"
+	prefixSize     = 6
+	secretSize     = 32
+	scopeSeparator = " "
+)
+
+var (
+	ErrMalformedKey = errors.New("malformed api key")
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrExpiredKey   = errors.New("api key expired")
+	ErrRevokedKey   = errors.New("api key revoked")
+)
+
+type Key struct {
+	Id         float64 `column:"id" mapstructure:"id"`
+	Name       string  `column:"name" mapstructure:"name"`
+	Prefix     string  `column:"prefix" mapstructure:"prefix"`
+	Hash       string  `column:"hash" mapstructure:"hash"`
+	Scopes     string  `column:"scopes" mapstructure:"scopes"`
+	CreatedAt  int64   `column:"created_at" mapstructure:"created_at"`
+	ExpiresAt  int64   `column:"expires_at" mapstructure:"expires_at"`
+	RevokedAt  int64   `column:"revoked_at" mapstructure:"revoked_at"`
+	LastUsedAt int64   `column:"last_used_at" mapstructure:"last_used_at"`
+}
+
+func (k *Key) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *Key) IsExpired(now time.Time) bool {
+	return k.ExpiresAt > 0 && now.Unix() >= k.ExpiresAt
+}
+
+func (k *Key) IsRevoked() bool {
+	return k.RevokedAt > 0
+}
+
+func (k *Key) matches(rawKey string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash(rawKey))) == 1
+}
+
+func generate() (prefix string, rawKey string, err error) {
+	prefixBytes := make([]byte, prefixSize)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+
+	secretBytes := make([]byte, secretSize)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+
+	prefix = keyPrefix + hex.EncodeToString(prefixBytes)
+	return prefix, prefix + "." + base64.RawURLEncoding.EncodeToString(secretBytes), nil
+}
+
+func parse(rawKey string) (string, error) {
+	prefix, secret, found := strings.Cut(rawKey, ".")
+	if !found || !strings.HasPrefix(prefix, keyPrefix) || len(secret) == 0 {
+		return "", ErrMalformedKey
+	}
+	return prefix, nil
+}
+
+func hash(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,123 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"github.com/yurikilian/bills/pkg/storage"
+	"strings"
+	"time"
+)
+
+const lastUsedResolution = time.Minute
+
+type Store struct {
+	storage storage.Storage[Key]
+	now     func() time.Time
+}
+
+func NewStore(storage storage.Storage[Key]) *Store {
+	return &Store{
+		storage: storage,
+		now:     time.Now,
+	}
+}
+
+func NewInMemoryStore() *Store {
+	return NewStore(storage.NewInMemoryStorage[Key]())
+}
+
+// PsqlSchema creates the table used by NewPsqlStore.
+const PsqlSchema = `CREATE TABLE IF NOT EXISTS api_keys (
+	id           BIGSERIAL PRIMARY KEY,
+	name         TEXT NOT NULL,
+	prefix       TEXT NOT NULL UNIQUE,
+	hash         TEXT NOT NULL,
+	scopes       TEXT NOT NULL DEFAULT '',
+	created_at   BIGINT NOT NULL,
+	expires_at   BIGINT NOT NULL DEFAULT 0,
+	revoked_at   BIGINT NOT NULL DEFAULT 0,
+	last_used_at BIGINT NOT NULL DEFAULT 0
+)`
+
+func NewPsqlStore(db *sql.DB) *Store {
+	tableName := "api_keys"
+	return NewStore(storage.GetPsql[Key](db, &tableName))
+}
+
+// Issue creates a new key and returns its plain text value. The plain text is
+// never stored, so it must be handed to the caller right away.
+func (s *Store) Issue(ctx context.Context, name string, scopes []string, ttl time.Duration) (string, *Key, error) {
+	prefix, rawKey, err := generate()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := s.now()
+	key := &Key{
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hash(rawKey),
+		Scopes:    strings.Join(scopes, scopeSeparator),
+		CreatedAt: now.Unix(),
+	}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl).Unix()
+	}
+
+	created, err := s.storage.Create(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	return rawKey, created, nil
+}
+
+func (s *Store) Verify(ctx context.Context, rawKey string) (*Key, error) {
+	prefix, err := parse(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.storage.FindBy(ctx, "prefix", prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil || !key.matches(rawKey) {
+		return nil, ErrInvalidKey
+	}
+
+	if key.IsRevoked() {
+		return nil, ErrRevokedKey
+	}
+
+	if key.IsExpired(s.now()) {
+		return nil, ErrExpiredKey
+	}
+
+	return key, nil
+}
+
+func (s *Store) Revoke(ctx context.Context, prefix string) error {
+	key, err := s.storage.FindBy(ctx, "prefix", prefix)
+	if err != nil {
+		return err
+	}
+
+	if key == nil {
+		return ErrInvalidKey
+	}
+
+	return s.storage.UpdateColumn(ctx, key.Id, "revoked_at", s.now().Unix())
+}
+
+// Touch records the key usage. Writes are skipped while the last recorded
+// usage is within lastUsedResolution to keep the hot path cheap. Only
+// last_used_at is written, so a key revoked since it was verified stays revoked.
+func (s *Store) Touch(ctx context.Context, key *Key) error {
+	now := s.now()
+	if now.Unix()-key.LastUsedAt < int64(lastUsedResolution.Seconds()) {
+		return nil
+	}
+
+	return s.storage.UpdateColumn(ctx, key.Id, "last_used_at", now.Unix())
+}
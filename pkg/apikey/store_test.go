@@ -0,0 +1,89 @@
+package apikey
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStore_Verify(t *testing.T) {
+	store := NewInMemoryStore()
+
+	validKey, _, err := store.Issue(context.Background(), "bank-sync", []string{"transactions:read"}, 0)
+	assert.NoError(t, err)
+
+	expiredKey, _, err := store.Issue(context.Background(), "expired", nil, time.Hour)
+	assert.NoError(t, err)
+
+	revokedKey, revoked, err := store.Issue(context.Background(), "revoked", nil, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Revoke(context.Background(), revoked.Prefix))
+
+	prefix, _ := parse(validKey)
+
+	tests := []struct {
+		name        string
+		rawKey      string
+		now         time.Time
+		expectedErr error
+	}{
+		{name: "Should verify given issued key", rawKey: validKey, now: time.Now()},
+		{name: "Should return malformed given key without secret", rawKey: "bk_123456", now: time.Now(), expectedErr: ErrMalformedKey},
+		{name: "Should return invalid given unknown prefix", rawKey: "bk_000000000000.secret", now: time.Now(), expectedErr: ErrInvalidKey},
+		{name: "Should return invalid given wrong secret", rawKey: prefix + ".wrong", now: time.Now(), expectedErr: ErrInvalidKey},
+		{name: "Should return expired given key after its ttl", rawKey: expiredKey, now: time.Now().Add(2 * time.Hour), expectedErr: ErrExpiredKey},
+		{name: "Should return revoked given revoked key", rawKey: revokedKey, now: time.Now(), expectedErr: ErrRevokedKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.now = func() time.Time { return tt.now }
+
+			key, err := store.Verify(context.Background(), tt.rawKey)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, key)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, key.HasScope("transactions:read"))
+			assert.False(t, key.HasScope("transactions:write"))
+		})
+	}
+}
+
+func TestStore_Touch(t *testing.T) {
+	store := NewInMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	rawKey, _, err := store.Issue(context.Background(), "bank-sync", nil, 0)
+	assert.NoError(t, err)
+
+	key, err := store.Verify(context.Background(), rawKey)
+	assert.NoError(t, err)
+	assert.Zero(t, key.LastUsedAt)
+
+	assert.NoError(t, store.Touch(context.Background(), key))
+
+	key, err = store.Verify(context.Background(), rawKey)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Unix(), key.LastUsedAt)
+}
+
+func TestStore_Touch_AfterRevoke(t *testing.T) {
+	store := NewInMemoryStore()
+
+	rawKey, _, err := store.Issue(context.Background(), "bank-sync", nil, 0)
+	assert.NoError(t, err)
+
+	key, err := store.Verify(context.Background(), rawKey)
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Revoke(context.Background(), key.Prefix))
+	assert.NoError(t, store.Touch(context.Background(), key))
+
+	_, err = store.Verify(context.Background(), rawKey)
+	assert.ErrorIs(t, err, ErrRevokedKey)
+}
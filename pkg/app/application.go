@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/server"
+	"google.golang.org/grpc"
+	"strings"
+)
+
+type mount struct {
+	prefix string
+	module Module
+}
+
+// Application wires modules together: it initializes them in dependency
+// order, mounts their routes and ties their lifecycle to a RestServer.
+type Application struct {
+	resources *Resources
+	mounts    []mount
+	ordered   []Module
+	started   []Module
+}
+
+func New(resources *Resources) *Application {
+	if resources == nil {
+		resources = &Resources{}
+	}
+	resources.modules = map[string]Module{}
+	return &Application{resources: resources}
+}
+
+// Mount adds a module whose routes are registered under prefix.
+func (a *Application) Mount(prefix string, module Module) *Application {
+	a.mounts = append(a.mounts, mount{prefix: prefix, module: module})
+	return a
+}
+
+// Attach initializes the modules, registers their routes on the server router
+// and starts and stops them with the server.
+func (a *Application) Attach(srv *server.RestServer) (*server.RestServer, error) {
+	if err := a.Init(srv.RestRouter()); err != nil {
+		return srv, err
+	}
+
+	return srv.OnStart(a.Start).OnShutdown(a.Stop), nil
+}
+
+// Init initializes the modules in dependency order and registers their routes.
+func (a *Application) Init(router *server.RestRouter) error {
+	ordered, err := a.order()
+	if err != nil {
+		return err
+	}
+
+	prefixes := map[string]string{}
+	for _, m := range a.mounts {
+		prefixes[m.module.Name()] = m.prefix
+	}
+
+	for _, module := range ordered {
+		if err := module.Init(a.resources); err != nil {
+			return fmt.Errorf("could not initialize module %v: %w", module.Name(), err)
+		}
+		a.resources.modules[module.Name()] = module
+		module.Register(router.Group(prefixes[module.Name()]))
+	}
+
+	a.ordered = ordered
+	return nil
+}
+
+// RegisterGrpc registers the gRPC services of the initialized modules
+// implementing GrpcModule.
+func (a *Application) RegisterGrpc(registrar grpc.ServiceRegistrar) {
+	for _, module := range a.ordered {
+		if grpcModule, ok := module.(GrpcModule); ok {
+			grpcModule.RegisterGrpc(registrar)
+		}
+	}
+}
+
+// Start starts the modules in dependency order. When one fails, the already
+// started ones are stopped.
+func (a *Application) Start(ctx context.Context) error {
+	for _, module := range a.ordered {
+		if err := module.Start(ctx); err != nil {
+			err = fmt.Errorf("could not start module %v: %w", module.Name(), err)
+			return errors.Join(err, a.Stop(ctx))
+		}
+		a.started = append(a.started, module)
+	}
+	return nil
+}
+
+// Stop stops the started modules in reverse dependency order.
+func (a *Application) Stop(ctx context.Context) error {
+	var errs []error
+	for i := len(a.started) - 1; i >= 0; i-- {
+		if err := a.started[i].Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("could not stop module %v: %w", a.started[i].Name(), err))
+		}
+	}
+	a.started = nil
+	return errors.Join(errs...)
+}
+
+// order sorts the modules so every module comes after its dependencies,
+// keeping the mount order otherwise.
+func (a *Application) order() ([]Module, error) {
+	modules := map[string]Module{}
+	for _, m := range a.mounts {
+		name := m.module.Name()
+		if _, exists := modules[name]; exists {
+			return nil, fmt.Errorf("module %v is mounted twice", name)
+		}
+		modules[name] = m.module
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	ordered := make([]Module, 0, len(modules))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("module dependency cycle: %v", strings.Join(append(path, name), " -> "))
+		}
+
+		module, ok := modules[name]
+		if !ok {
+			return fmt.Errorf("module %v depends on %v, which is not mounted", path[len(path)-1], name)
+		}
+
+		state[name] = visiting
+		next := append(append([]string(nil), path...), name)
+		for _, dependency := range module.Dependencies() {
+			if err := visit(dependency, next); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, module)
+		return nil
+	}
+
+	for _, m := range a.mounts {
+		if err := visit(m.module.Name(), nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"net"
+	"net/http"
+	"testing"
+)
+
+type fakeModule struct {
+	name         string
+	dependencies []string
+	startErr     error
+	events       *[]string
+}
+
+func (m *fakeModule) Name() string           { return m.name }
+func (m *fakeModule) Dependencies() []string { return m.dependencies }
+
+func (m *fakeModule) Init(resources *Resources) error {
+	for _, dependency := range m.dependencies {
+		if _, ok := resources.Module(dependency); !ok {
+			return errors.New(dependency + " is not initialized")
+		}
+	}
+	*m.events = append(*m.events, "init "+m.name)
+	return nil
+}
+
+func (m *fakeModule) Register(router *server.RestRouter) {
+	router.Get("/", func(ctx server.IHttpContext) error { return nil })
+}
+
+func (m *fakeModule) Start(ctx context.Context) error {
+	if m.startErr != nil {
+		return m.startErr
+	}
+	*m.events = append(*m.events, "start "+m.name)
+	return nil
+}
+
+func (m *fakeModule) Stop(ctx context.Context) error {
+	*m.events = append(*m.events, "stop "+m.name)
+	return nil
+}
+
+func TestApplication(t *testing.T) {
+	events := make([]string, 0)
+	router := server.NewRestRouter()
+
+	application := New(nil).
+		Mount("/reports", &fakeModule{name: "reports", dependencies: []string{"transaction", "users"}, events: &events}).
+		Mount("/transactions", &fakeModule{name: "transaction", dependencies: []string{"users"}, events: &events}).
+		Mount("/users", &fakeModule{name: "users", events: &events})
+
+	assert.NoError(t, application.Init(router))
+	assert.NoError(t, application.Start(context.Background()))
+	assert.NoError(t, application.Stop(context.Background()))
+
+	assert.Equal(t, []string{
+		"init users", "init transaction", "init reports",
+		"start users", "start transaction", "start reports",
+		"stop reports", "stop transaction", "stop users",
+	}, events)
+	assert.Equal(t, []server.Route{
+		{Method: http.MethodGet, Path: "/reports"},
+		{Method: http.MethodGet, Path: "/transactions"},
+		{Method: http.MethodGet, Path: "/users"},
+	}, router.Routes())
+}
+
+func TestApplication_Errors(t *testing.T) {
+	events := make([]string, 0)
+
+	tests := []struct {
+		name    string
+		modules []Module
+		wantErr string
+	}{
+		{
+			name: "Should report dependency cycles",
+			modules: []Module{
+				&fakeModule{name: "a", dependencies: []string{"b"}, events: &events},
+				&fakeModule{name: "b", dependencies: []string{"c"}, events: &events},
+				&fakeModule{name: "c", dependencies: []string{"a"}, events: &events},
+			},
+			wantErr: "module dependency cycle: a -> b -> c -> a",
+		},
+		{
+			name:    "Should report missing dependencies",
+			modules: []Module{&fakeModule{name: "a", dependencies: []string{"b"}, events: &events}},
+			wantErr: "module a depends on b, which is not mounted",
+		},
+		{
+			name: "Should report modules mounted twice",
+			modules: []Module{
+				&fakeModule{name: "a", events: &events},
+				&fakeModule{name: "a", events: &events},
+			},
+			wantErr: "module a is mounted twice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			application := New(nil)
+			for _, module := range tt.modules {
+				application.Mount("/", module)
+			}
+
+			assert.EqualError(t, application.Init(server.NewRestRouter()), tt.wantErr)
+		})
+	}
+}
+
+func TestApplication_Start_StopsStartedModules(t *testing.T) {
+	events := make([]string, 0)
+	application := New(nil).
+		Mount("/a", &fakeModule{name: "a", events: &events}).
+		Mount("/b", &fakeModule{name: "b", startErr: errors.New("boom"), events: &events})
+
+	assert.NoError(t, application.Init(server.NewRestRouter()))
+	assert.EqualError(t, application.Start(context.Background()), "could not start module b: boom")
+	assert.Equal(t, []string{"init a", "init b", "start a", "stop a"}, events)
+}
+
+func TestApplication_Attach_StopsModulesWhenListenFails(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer occupied.Close()
+
+	events := make([]string, 0)
+	restServer := server.NewRestServer(server.NewRestServerOptions(occupied.Addr().String(), logger.NewProvider().ProvideLog()))
+	_, err = New(nil).
+		Mount("/a", &fakeModule{name: "a", events: &events}).
+		Attach(restServer)
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, restServer.Run(context.Background()), "address already in use")
+	assert.Equal(t, []string{"init a", "start a", "stop a"}, events)
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"github.com/yurikilian/bills/pkg/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"google.golang.org/grpc"
+)
+
+// Module is a self-contained feature of the application, such as the
+// transaction API.
+type Module interface {
+	// Name identifies the module in Dependencies and in errors.
+	Name() string
+	// Dependencies lists the modules that must be initialized and started first.
+	Dependencies() []string
+	// Init builds the module from the shared resources. Dependencies are
+	// already initialized and can be looked up through the resources.
+	Init(resources *Resources) error
+	// Register adds the module routes, the router is scoped to its prefix.
+	Register(router *server.RestRouter)
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// GrpcModule is implemented by modules also exposing gRPC services.
+type GrpcModule interface {
+	RegisterGrpc(registrar grpc.ServiceRegistrar)
+}
+
+// Resources are shared by every module of an application.
+type Resources struct {
+	Log    logger.Logger
+	DB     *sql.DB
+	Config *server.ConfigurationProvider
+
+	modules map[string]Module
+}
+
+// Module returns an initialized module by name.
+func (r *Resources) Module(name string) (Module, bool) {
+	module, ok := r.modules[name]
+	return module, ok
+}
+
+// NopLifecycle can be embedded by modules without Start and Stop logic.
+type NopLifecycle struct{}
+
+func (NopLifecycle) Start(context.Context) error {
+	return nil
+}
+
+func (NopLifecycle) Stop(context.Context) error {
+	return nil
+}
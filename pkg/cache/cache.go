@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Tags       []string
+	StoredAt   time.Time
+	FreshUntil time.Time
+	StaleUntil time.Time
+}
+
+func (e *Entry) IsFresh(now time.Time) bool {
+	return now.Before(e.FreshUntil)
+}
+
+func (e *Entry) IsUsableStale(now time.Time) bool {
+	return now.Before(e.StaleUntil)
+}
+
+func (e *Entry) size() int {
+	size := len(e.Body)
+	for name, values := range e.Header {
+		size += len(name)
+		for _, value := range values {
+			size += len(value)
+		}
+	}
+	for _, tag := range e.Tags {
+		size += len(tag)
+	}
+	return size
+}
+
+type Cache interface {
+	Get(ctx context.Context, key string) (*Entry, bool)
+	Set(ctx context.Context, key string, entry *Entry)
+	InvalidateTags(ctx context.Context, tags ...string)
+}
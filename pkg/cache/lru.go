@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+type lruItem struct {
+	key   string
+	entry *Entry
+	size  int
+}
+
+// LRU evicts the least recently used entries once the stored bytes exceed
+// maxBytes. Entries larger than the whole budget are never stored.
+type LRU struct {
+	mu        sync.Mutex
+	maxBytes  int
+	usedBytes int
+	order     *list.List
+	items     map[string]*list.Element
+	tags      map[string]map[string]struct{}
+}
+
+func NewLRU(maxBytes int) *LRU {
+	return &LRU{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+		tags:     map[string]map[string]struct{}{},
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*lruItem).entry, true
+}
+
+func (c *LRU) Set(_ context.Context, key string, entry *Entry) {
+	size := len(key) + entry.size()
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+
+	c.items[key] = c.order.PushFront(&lruItem{key: key, entry: entry, size: size})
+	c.usedBytes += size
+	for _, tag := range entry.Tags {
+		if _, ok := c.tags[tag]; !ok {
+			c.tags[tag] = map[string]struct{}{}
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.usedBytes > c.maxBytes {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *LRU) InvalidateTags(_ context.Context, tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if element, ok := c.items[key]; ok {
+				c.removeElement(element)
+			}
+		}
+		delete(c.tags, tag)
+	}
+}
+
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) removeElement(element *list.Element) {
+	item := c.order.Remove(element).(*lruItem)
+	delete(c.items, item.key)
+	c.usedBytes -= item.size
+
+	for _, tag := range item.entry.Tags {
+		delete(c.tags[tag], item.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+var _ Cache = (*LRU)(nil)
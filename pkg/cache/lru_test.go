@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should evict least recently used entry given byte budget exceeded", func(t *testing.T) {
+		lru := NewLRU(30)
+		lru.Set(ctx, "a", &Entry{Body: []byte("0123456789")})
+		lru.Set(ctx, "b", &Entry{Body: []byte("0123456789")})
+
+		_, ok := lru.Get(ctx, "a")
+		assert.True(t, ok)
+
+		lru.Set(ctx, "c", &Entry{Body: []byte("0123456789")})
+
+		_, ok = lru.Get(ctx, "b")
+		assert.False(t, ok)
+		_, ok = lru.Get(ctx, "a")
+		assert.True(t, ok)
+		_, ok = lru.Get(ctx, "c")
+		assert.True(t, ok)
+	})
+
+	t.Run("Should not store entry given it is larger than the budget", func(t *testing.T) {
+		lru := NewLRU(5)
+		lru.Set(ctx, "a", &Entry{Body: []byte("0123456789")})
+
+		assert.Equal(t, 0, lru.Len())
+	})
+
+	t.Run("Should remove every tagged entry given tag invalidation", func(t *testing.T) {
+		lru := NewLRU(1024)
+		lru.Set(ctx, "summary", &Entry{Body: []byte("1"), Tags: []string{"transactions"}})
+		lru.Set(ctx, "list", &Entry{Body: []byte("2"), Tags: []string{"transactions", "lists"}})
+		lru.Set(ctx, "other", &Entry{Body: []byte("3"), Tags: []string{"categories"}})
+
+		lru.InvalidateTags(ctx, "transactions")
+
+		assert.Equal(t, 1, lru.Len())
+		_, ok := lru.Get(ctx, "other")
+		assert.True(t, ok)
+	})
+}
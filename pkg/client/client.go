@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tracerName = "github.com/yurikilian/bills/pkg/client"
+	// maxErrorBody bounds the detail of errors whose body is not a problem.
+	maxErrorBody = 512
+)
+
+// Client calls the bills API. It is safe for concurrent use.
+type Client struct {
+	options *Options
+	baseURL string
+}
+
+func New(options *Options) (*Client, error) {
+	if err := server.Validator.Validate(options); err != nil {
+		return nil, err
+	}
+	if options.HttpClient == nil {
+		options.HttpClient = http.DefaultClient
+	}
+
+	return &Client{
+		options: options,
+		baseURL: strings.TrimSuffix(options.BaseURL, "/"),
+	}, nil
+}
+
+type call struct {
+	name   string
+	method string
+	path   string
+	body   interface{}
+	header http.Header
+	// idempotent calls are retried.
+	idempotent bool
+}
+
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// do runs the call, retrying idempotent ones, and decodes a successful body
+// into result.
+func (c *Client) do(ctx context.Context, call *call, result interface{}) error {
+	var body []byte
+	if call.body != nil {
+		var err error
+		if body, err = json.Marshal(call.body); err != nil {
+			return err
+		}
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, call.name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", call.method),
+			attribute.String("http.url", c.baseURL+call.path)))
+	defer span.End()
+
+	attempts := 1
+	if call.idempotent {
+		attempts = c.options.Retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(ctx, call, body)
+		if err == nil {
+			span.SetAttributes(attribute.Int("http.status_code", resp.statusCode))
+			if resp.statusCode < http.StatusBadRequest {
+				return decode(resp, result)
+			}
+			err = decodeError(resp)
+		}
+
+		if attempt >= attempts || !c.retryable(ctx, err) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt)))
+		select {
+		case <-time.After(c.backoff(attempt, resp)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, call *call, body []byte) (*response, error) {
+	if c.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.Timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, call.method, c.baseURL+call.path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(c.options.ApiKey) > 0 {
+		req.Header.Set("X-API-Key", c.options.ApiKey)
+	}
+	for key, values := range call.header {
+		req.Header[key] = values
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.options.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &response{statusCode: resp.StatusCode, header: resp.Header, body: respBody}, nil
+}
+
+// retryable reports whether err is temporary: a retryable status, or a
+// network error or attempt timeout while the caller context is still alive.
+// Other errors, e.g. an invalid request, would fail again.
+func (c *Client) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	// url.Error is itself a net.Error, the transport error it wraps decides.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// backoff returns the Retry-After of the response when set, an exponential
+// backoff with full jitter otherwise, never more than MaxBackoff.
+func (c *Client) backoff(attempt int, resp *response) time.Duration {
+	retry := c.options.Retry
+
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.header.Get("Retry-After")); ok {
+			if retryAfter > retry.MaxBackoff {
+				return retry.MaxBackoff
+			}
+			return retryAfter
+		}
+	}
+
+	ceiling := retry.InitialBackoff << (attempt - 1)
+	if ceiling > retry.MaxBackoff || ceiling <= 0 {
+		ceiling = retry.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func decode(resp *response, result interface{}) error {
+	if result == nil || len(bytes.TrimSpace(resp.body)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.body, result); err != nil {
+		return fmt.Errorf("could not decode the response: %w", err)
+	}
+	return nil
+}
+
+// decodeError reads the problem of a failed response. Bodies which are not a
+// problem, e.g. from a proxy, are kept as the detail.
+func decodeError(resp *response) error {
+	apiErr := &Error{}
+	if err := json.Unmarshal(resp.body, apiErr); err == nil && apiErr.Code != 0 {
+		return apiErr
+	}
+
+	detail := strings.TrimSpace(string(resp.body))
+	if len(detail) > maxErrorBody {
+		detail = detail[:maxErrorBody]
+	}
+	return &Error{
+		Code:   resp.statusCode,
+		Title:  http.StatusText(resp.statusCode),
+		Detail: detail,
+	}
+}
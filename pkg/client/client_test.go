@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/internal/transaction"
+	"github.com/yurikilian/bills/pkg/app"
+	"github.com/yurikilian/bills/pkg/middleware"
+	"github.com/yurikilian/bills/pkg/server"
+	"github.com/yurikilian/bills/pkg/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newApi(t *testing.T) *httptest.Server {
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Use(middleware.Otel()).
+		Use(middleware.Json())
+
+	module := transaction.NewTransactionModuleBuilder().WithInMemoryStorage(storage.NewInMemoryStorage[transaction.Entity]())
+	_, err := app.New(nil).Mount("/transactions", module).Attach(restServer)
+	require.NoError(t, err)
+
+	api := httptest.NewServer(restServer)
+	t.Cleanup(api.Close)
+	return api
+}
+
+func TestClient_Transactions(t *testing.T) {
+	api := newApi(t)
+	c, err := New(NewOptions(api.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, title := range []string{"Rent", "Supermarket"} {
+		err = c.CreateTransaction(ctx, CreateTransactionRequest{Title: title, Description: title, Price: 10, Currency: "EUR", Type: "DEBIT"})
+		require.NoError(t, err)
+	}
+
+	found, err := c.FindTransaction(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &Transaction{Id: 2, Title: "Supermarket", Description: "Supermarket", Price: 10, Currency: "EUR", Type: "DEBIT"}, found)
+
+	missing, err := c.FindTransaction(ctx, 42)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	page, err := c.ListTransactions(ctx, ListTransactionsRequest{Offset: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "Supermarket", page[0].Title)
+
+	err = c.CreateTransaction(ctx, CreateTransactionRequest{Title: "Rent", Description: "Flat", Price: 700, Currency: "USD", Type: "DEBIT"})
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.Code)
+	assert.Equal(t, "https://mybils.io/problems/invalid-request", apiErr.Type)
+	assert.Equal(t, []string{"Currency value must be one of the following: EUR"}, apiErr.FieldErrors)
+
+	_, err = c.ListTransactions(ctx, ListTransactionsRequest{Limit: 1000})
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.Code)
+	assert.Equal(t, []string{"Limit value must be lower than 500"}, apiErr.FieldErrors)
+}
+
+func TestClient_Retry(t *testing.T) {
+	retry := &RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		options      func(options *Options) *Options
+		statuses     []int
+		call         func(c *Client) error
+		wantAttempts int32
+		wantCode     int
+	}{
+		{
+			name:         "Should retry idempotent calls given temporary failures",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			call:         func(c *Client) error { _, err := c.FindTransaction(context.Background(), 1); return err },
+			wantAttempts: 3,
+		},
+		{
+			name:         "Should give up after the last attempt",
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			call:         func(c *Client) error { _, err := c.FindTransaction(context.Background(), 1); return err },
+			wantAttempts: 3,
+			wantCode:     http.StatusBadGateway,
+		},
+		{
+			name:         "Should not retry client errors",
+			statuses:     []int{http.StatusBadRequest, http.StatusOK},
+			call:         func(c *Client) error { _, err := c.FindTransaction(context.Background(), 1); return err },
+			wantAttempts: 1,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Should not retry creations",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusNoContent},
+			call:         func(c *Client) error { return c.CreateTransaction(context.Background(), CreateTransactionRequest{}) },
+			wantAttempts: 1,
+			wantCode:     http.StatusServiceUnavailable,
+		},
+		{
+			name:         "Should retry creations given idempotency keys",
+			options:      func(options *Options) *Options { return options.WithIdempotentCreate() },
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusNoContent},
+			call:         func(c *Client) error { return c.CreateTransaction(context.Background(), CreateTransactionRequest{}) },
+			wantAttempts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			keys := map[string]bool{}
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := atomic.AddInt32(&attempts, 1)
+				keys[r.Header.Get("Idempotency-Key")] = true
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[attempt-1])
+				if tt.statuses[attempt-1] == http.StatusOK {
+					_, _ = w.Write([]byte(`{"Id":1}`))
+				}
+			}))
+			defer api.Close()
+
+			options := NewOptions(api.URL).WithRetry(retry)
+			if tt.options != nil {
+				options = tt.options(options)
+			}
+			c, err := New(options)
+			require.NoError(t, err)
+
+			err = tt.call(c)
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Len(t, keys, 1)
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var apiErr *Error
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.wantCode, apiErr.Code)
+		})
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer api.Close()
+
+	c, err := New(NewOptions(api.URL).WithTimeout(20 * time.Millisecond).WithoutRetry())
+	require.NoError(t, err)
+
+	_, err = c.FindTransaction(context.Background(), 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClient_Retry_TransportErrors(t *testing.T) {
+	retry := &RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int32
+	}{
+		{
+			name:         "Should retry given network errors",
+			err:          &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			wantAttempts: 3,
+		},
+		{
+			name:         "Should retry given timeouts",
+			err:          context.DeadlineExceeded,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Should not retry given other errors",
+			err:          errors.New("x509: certificate signed by unknown authority"),
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			httpClient := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&attempts, 1)
+				return nil, tt.err
+			})}
+
+			c, err := New(NewOptions("http://bills.test").WithRetry(retry).WithHttpClient(httpClient))
+			require.NoError(t, err)
+
+			_, err = c.FindTransaction(context.Background(), 1)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.wantAttempts, attempts)
+		})
+	}
+}
+
+func TestClient_Propagation(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		_ = provider.Shutdown(context.Background())
+	}()
+
+	traceparent := make(chan string, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer api.Close()
+
+	c, err := New(NewOptions(api.URL))
+	require.NoError(t, err)
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "caller")
+	require.NoError(t, c.CreateTransaction(ctx, CreateTransactionRequest{}))
+	span.End()
+
+	assert.Contains(t, <-traceparent, span.SpanContext().TraceID().String())
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(NewOptions("not a url"))
+	assert.ErrorContains(t, err, "Field validation for 'BaseURL' failed on the 'url' tag")
+
+	_, err = New(NewOptions("http://localhost:3500").WithRetry(&RetryOptions{MaxAttempts: 0, InitialBackoff: time.Second, MaxBackoff: time.Second}))
+	assert.ErrorContains(t, err, "Field validation for 'MaxAttempts' failed on the 'gte' tag")
+}
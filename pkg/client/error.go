@@ -0,0 +1,42 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is a failed call, decoded from the problem body returned by the API.
+type Error struct {
+	// Code is the HTTP status of the problem.
+	Code        int      `json:"code"`
+	Title       string   `json:"title"`
+	Detail      string   `json:"detail"`
+	Instance    string   `json:"instance"`
+	Type        string   `json:"type"`
+	FieldErrors []string `json:"fieldErrors,omitempty"`
+}
+
+func (e *Error) Error() string {
+	message := e.Detail
+	if len(message) == 0 {
+		message = e.Title
+	}
+	if len(e.FieldErrors) > 0 {
+		message += ": " + strings.Join(e.FieldErrors, ", ")
+	}
+	return fmt.Sprintf("%v %v", e.Code, message)
+}
+
+// Temporary reports whether the call may succeed when retried later.
+func (e *Error) Temporary() bool {
+	return retryableStatus(e.Code)
+}
+
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
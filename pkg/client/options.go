@@ -0,0 +1,77 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+type Options struct {
+	// BaseURL is the address of the API, e.g. https://bills.internal:3500.
+	BaseURL string `validate:"required,url"`
+	// Timeout bounds every attempt of a call, retries included separately.
+	Timeout time.Duration `validate:"gte=0"`
+	Retry   *RetryOptions `validate:"required"`
+	// ApiKey is sent in the X-API-Key header when set.
+	ApiKey string
+	// IdempotentCreate sends an Idempotency-Key with creations so they are
+	// retried as well. The server must run the Idempotency middleware.
+	IdempotentCreate bool
+	HttpClient       *http.Client
+}
+
+// RetryOptions configures the retries of idempotent calls failing with a
+// network error or a 429, 502, 503 or 504 status. Backoffs grow exponentially
+// with full jitter, a Retry-After header takes precedence.
+type RetryOptions struct {
+	MaxAttempts    int           `validate:"gte=1"`
+	InitialBackoff time.Duration `validate:"gt=0"`
+	MaxBackoff     time.Duration `validate:"gtefield=InitialBackoff"`
+}
+
+func NewOptions(baseURL string) *Options {
+	return &Options{
+		BaseURL:    baseURL,
+		Timeout:    10 * time.Second,
+		Retry:      NewRetryOptions(),
+		HttpClient: http.DefaultClient,
+	}
+}
+
+func NewRetryOptions() *RetryOptions {
+	return &RetryOptions{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
+
+func (o *Options) WithTimeout(timeout time.Duration) *Options {
+	o.Timeout = timeout
+	return o
+}
+
+func (o *Options) WithRetry(retry *RetryOptions) *Options {
+	o.Retry = retry
+	return o
+}
+
+// WithoutRetry makes a single attempt per call.
+func (o *Options) WithoutRetry() *Options {
+	o.Retry = &RetryOptions{MaxAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	return o
+}
+
+func (o *Options) WithApiKey(apiKey string) *Options {
+	o.ApiKey = apiKey
+	return o
+}
+
+func (o *Options) WithIdempotentCreate() *Options {
+	o.IdempotentCreate = true
+	return o
+}
+
+func (o *Options) WithHttpClient(httpClient *http.Client) *Options {
+	o.HttpClient = httpClient
+	return o
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const (
+	transactionsPath = "/transactions"
+	rpcPath          = transactionsPath + "/rpc"
+)
+
+type Transaction struct {
+	Id          float64 `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Currency    string  `json:"currency"`
+	Type        string  `json:"type"`
+}
+
+type CreateTransactionRequest struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	// Currency only supports EUR.
+	Currency string `json:"currency"`
+	// Type is either CREDIT or DEBIT.
+	Type string `json:"type"`
+}
+
+type ListTransactionsRequest struct {
+	Offset int `json:"offset"`
+	// Limit defaults to 50 and is capped at 500 by the API.
+	Limit int `json:"limit,omitempty"`
+}
+
+// CreateTransaction creates a transaction. It is only retried when the
+// options enable IdempotentCreate.
+func (c *Client) CreateTransaction(ctx context.Context, request CreateTransactionRequest) error {
+	create := &call{
+		name:   "transactions.create",
+		method: http.MethodPost,
+		path:   transactionsPath,
+		body:   request,
+	}
+
+	if c.options.IdempotentCreate {
+		key, err := idempotencyKey()
+		if err != nil {
+			return err
+		}
+		create.header = http.Header{"Idempotency-Key": []string{key}}
+		create.idempotent = true
+	}
+
+	return c.do(ctx, create, nil)
+}
+
+// FindTransaction returns the transaction with the id, or nil when there is none.
+func (c *Client) FindTransaction(ctx context.Context, id float64) (*Transaction, error) {
+	var transaction *Transaction
+	err := c.do(ctx, &call{
+		name:       "transactions.find",
+		method:     http.MethodGet,
+		path:       transactionsPath + "?id=" + strconv.FormatFloat(id, 'f', -1, 64),
+		idempotent: true,
+	}, &transaction)
+	return transaction, err
+}
+
+// ListTransactions returns a page of transactions ordered by id. The REST API
+// has no list route, it calls the transaction.list JSON-RPC method.
+func (c *Client) ListTransactions(ctx context.Context, request ListTransactionsRequest) ([]Transaction, error) {
+	transactions := make([]Transaction, 0)
+	err := c.rpc(ctx, "transaction.list", request, &transactions)
+	return transactions, err
+}
+
+type rpcRequest struct {
+	JsonRpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	Id      int         `json:"id"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	} `json:"error"`
+}
+
+// rpc calls a read-only JSON-RPC method, so it is retried. Errors carrying a
+// problem are decoded into an Error like REST ones.
+func (c *Client) rpc(ctx context.Context, method string, params interface{}, result interface{}) error {
+	var resp rpcResponse
+	err := c.do(ctx, &call{
+		name:       method,
+		method:     http.MethodPost,
+		path:       rpcPath,
+		body:       rpcRequest{JsonRpc: "2.0", Method: method, Params: params, Id: 1},
+		idempotent: true,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.Error != nil {
+		apiErr := &Error{}
+		if err := json.Unmarshal(resp.Error.Data, apiErr); err == nil && apiErr.Code != 0 {
+			return apiErr
+		}
+		return &Error{Code: rpcStatus(resp.Error.Code), Title: resp.Error.Message}
+	}
+
+	return json.Unmarshal(resp.Result, result)
+}
+
+// rpcStatus maps the JSON-RPC codes without problem data to an HTTP status.
+func rpcStatus(code int) int {
+	switch code {
+	case -32700, -32600, -32602:
+		return http.StatusBadRequest
+	case -32601:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func idempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
@@ -0,0 +1,127 @@
+package csp
+
+import (
+	"strings"
+)
+
+type Source string
+
+const (
+	Self          Source = "'self'"
+	None          Source = "'none'"
+	UnsafeInline  Source = "'unsafe-inline'"
+	UnsafeEval    Source = "'unsafe-eval'"
+	StrictDynamic Source = "'strict-dynamic'"
+	Data          Source = "data:"
+	Https         Source = "https:"
+	// Nonce is replaced by the per-request nonce when the policy is built.
+	Nonce Source = "'nonce-{nonce}'"
+)
+
+func Host(host string) Source {
+	return Source(host)
+}
+
+type directive struct {
+	name    string
+	sources []Source
+}
+
+type Policy struct {
+	directives []directive
+}
+
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+func (p *Policy) DefaultSrc(sources ...Source) *Policy {
+	return p.set("default-src", sources)
+}
+
+func (p *Policy) ScriptSrc(sources ...Source) *Policy {
+	return p.set("script-src", sources)
+}
+
+func (p *Policy) StyleSrc(sources ...Source) *Policy {
+	return p.set("style-src", sources)
+}
+
+func (p *Policy) ImgSrc(sources ...Source) *Policy {
+	return p.set("img-src", sources)
+}
+
+func (p *Policy) ConnectSrc(sources ...Source) *Policy {
+	return p.set("connect-src", sources)
+}
+
+func (p *Policy) FontSrc(sources ...Source) *Policy {
+	return p.set("font-src", sources)
+}
+
+func (p *Policy) ObjectSrc(sources ...Source) *Policy {
+	return p.set("object-src", sources)
+}
+
+func (p *Policy) FrameAncestors(sources ...Source) *Policy {
+	return p.set("frame-ancestors", sources)
+}
+
+func (p *Policy) BaseUri(sources ...Source) *Policy {
+	return p.set("base-uri", sources)
+}
+
+func (p *Policy) FormAction(sources ...Source) *Policy {
+	return p.set("form-action", sources)
+}
+
+func (p *Policy) UpgradeInsecureRequests() *Policy {
+	return p.set("upgrade-insecure-requests", nil)
+}
+
+func (p *Policy) ReportUri(uri string) *Policy {
+	return p.set("report-uri", []Source{Source(uri)})
+}
+
+func (p *Policy) UsesNonce() bool {
+	for _, d := range p.directives {
+		for _, source := range d.sources {
+			if source == Nonce {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Policy) Build(nonce string) string {
+	var sb strings.Builder
+
+	for i, d := range p.directives {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(d.name)
+		for _, source := range d.sources {
+			sb.WriteString(" ")
+			if source == Nonce {
+				sb.WriteString("'nonce-" + nonce + "'")
+			} else {
+				sb.WriteString(string(source))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func (p *Policy) set(name string, sources []Source) *Policy {
+	for i, d := range p.directives {
+		if d.name == name {
+			p.directives[i].sources = sources
+			return p
+		}
+	}
+	p.directives = append(p.directives, directive{name: name, sources: sources})
+	return p
+}
@@ -0,0 +1,37 @@
+package csp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPolicy_Build(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *Policy
+		nonce    string
+		expected string
+	}{
+		{
+			name:     "Should build json api policy",
+			policy:   NewPolicy().DefaultSrc(None).FrameAncestors(None),
+			expected: "default-src 'none'; frame-ancestors 'none'",
+		},
+		{
+			name:     "Should replace nonce given nonce source",
+			policy:   NewPolicy().DefaultSrc(Self).ScriptSrc(Self, Nonce).UpgradeInsecureRequests(),
+			nonce:    "abc",
+			expected: "default-src 'self'; script-src 'self' 'nonce-abc'; upgrade-insecure-requests",
+		},
+		{
+			name:     "Should override directive given it is set twice",
+			policy:   NewPolicy().DefaultSrc(None).DefaultSrc(Self, Host("https://cdn.mybils.io")),
+			expected: "default-src 'self' https://cdn.mybils.io",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Build(tt.nonce))
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var errNoRequestScope = errors.New("the request has no dependency scope, is the RequestScope middleware registered?")
+
+type Lifetime int
+
+const (
+	// Singleton values are created once per container.
+	Singleton Lifetime = iota
+	// Scoped values are created once per scope, usually one per request.
+	Scoped
+)
+
+func (l Lifetime) String() string {
+	if l == Scoped {
+		return "scoped"
+	}
+	return "singleton"
+}
+
+type Factory[T any] func(s *Scope) (T, error)
+
+type provider struct {
+	typ      reflect.Type
+	lifetime Lifetime
+	create   func(s *Scope) (interface{}, error)
+	// binding providers forward to the implementation and cache nothing.
+	binding bool
+	// external values are owned, and closed, by the caller.
+	external bool
+}
+
+// Container holds the providers of an application. Registration is not safe
+// for concurrent use and must be done before Validate.
+type Container struct {
+	providers map[reflect.Type]*provider
+	errs      []error
+	root      *Scope
+}
+
+func New() *Container {
+	c := &Container{providers: map[reflect.Type]*provider{}}
+	c.root = newScope(c, nil)
+	return c
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (c *Container) register(p *provider) {
+	if _, exists := c.providers[p.typ]; exists {
+		c.errs = append(c.errs, fmt.Errorf("%v is provided twice", p.typ))
+		return
+	}
+	c.providers[p.typ] = p
+}
+
+// Provide registers the factory of T.
+func Provide[T any](c *Container, lifetime Lifetime, factory Factory[T]) {
+	c.register(&provider{
+		typ:      typeOf[T](),
+		lifetime: lifetime,
+		create: func(s *Scope) (interface{}, error) {
+			return factory(s)
+		},
+	})
+}
+
+// Value registers an already built singleton, which is never closed by the
+// container.
+func Value[T any](c *Container, value T) {
+	c.register(&provider{
+		typ:      typeOf[T](),
+		lifetime: Singleton,
+		external: true,
+		create: func(*Scope) (interface{}, error) {
+			return value, nil
+		},
+	})
+}
+
+// Bind resolves the interface I with the provider of Impl, sharing its
+// lifetime and instance.
+func Bind[I any, Impl any](c *Container) {
+	iface, impl := typeOf[I](), typeOf[Impl]()
+	if iface.Kind() != reflect.Interface {
+		c.errs = append(c.errs, fmt.Errorf("cannot bind %v, it is not an interface", iface))
+		return
+	}
+	if !impl.Implements(iface) {
+		c.errs = append(c.errs, fmt.Errorf("cannot bind %v to %v, it does not implement it", iface, impl))
+		return
+	}
+
+	c.register(&provider{
+		typ:     iface,
+		binding: true,
+		create: func(s *Scope) (interface{}, error) {
+			return s.resolve(impl)
+		},
+	})
+}
+
+// Validate reports registration errors, dependency cycles and missing
+// providers by resolving every provider, scoped values in a throwaway scope.
+// It is meant to run once at startup.
+func (c *Container) Validate() error {
+	errs := append([]error(nil), c.errs...)
+	reported := map[string]bool{}
+
+	scope := c.NewScope()
+	defer scope.Close()
+
+	types := make([]reflect.Type, 0, len(c.providers))
+	for typ := range c.providers {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].String() < types[j].String() })
+
+	for _, typ := range types {
+		// Singletons are always created in the root scope.
+		_, err := scope.resolve(typ)
+		// Every provider of a cycle reports it, keep a single occurrence.
+		if err != nil && !reported[err.Error()] {
+			reported[err.Error()] = true
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Root is the scope singletons are resolved from.
+func (c *Container) Root() *Scope {
+	return c.root
+}
+
+// NewScope returns a scope for scoped values, which must be closed once done.
+func (c *Container) NewScope() *Scope {
+	return newScope(c, c.root)
+}
+
+// Close closes the singletons implementing io.Closer, in reverse creation order.
+func (c *Container) Close() error {
+	return c.root.Close()
+}
+
+type instance struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+type scopeState struct {
+	mu        sync.Mutex
+	instances map[reflect.Type]*instance
+	created   []interface{}
+}
+
+// Scope resolves values. The same scope value is a view used by a single
+// resolution chain, so it can detect cycles.
+type Scope struct {
+	container *Container
+	parent    *Scope
+	state     *scopeState
+	chain     []reflect.Type
+}
+
+func newScope(c *Container, parent *Scope) *Scope {
+	return &Scope{
+		container: c,
+		parent:    parent,
+		state:     &scopeState{instances: map[reflect.Type]*instance{}},
+	}
+}
+
+// Resolve returns the value provided for T.
+func Resolve[T any](s *Scope) (T, error) {
+	var zero T
+
+	value, err := s.resolve(typeOf[T]())
+	if err != nil {
+		return zero, err
+	}
+	typed, _ := value.(T)
+	return typed, nil
+}
+
+func (s *Scope) resolve(typ reflect.Type) (interface{}, error) {
+	for i, resolving := range s.chain {
+		if resolving == typ {
+			return nil, &CycleError{Chain: append(append([]reflect.Type(nil), s.chain[i:]...), typ)}
+		}
+	}
+
+	p, ok := s.container.providers[typ]
+	if !ok {
+		return nil, s.errorf("no provider for %v", typ)
+	}
+
+	if p.binding {
+		return p.create(s.next(s, typ))
+	}
+
+	owner := s
+	if p.lifetime == Singleton {
+		owner = s.container.root
+	} else if s.parent == nil {
+		return nil, s.errorf("scoped %v cannot be resolved outside of a scope, is a singleton depending on it?", typ)
+	}
+
+	owner.state.mu.Lock()
+	inst, exists := owner.state.instances[typ]
+	if !exists {
+		inst = &instance{}
+		owner.state.instances[typ] = inst
+	}
+	owner.state.mu.Unlock()
+
+	inst.once.Do(func() {
+		inst.value, inst.err = p.create(s.next(owner, typ))
+		if inst.err != nil || p.external {
+			return
+		}
+
+		owner.state.mu.Lock()
+		owner.state.created = append(owner.state.created, inst.value)
+		owner.state.mu.Unlock()
+	})
+	return inst.value, inst.err
+}
+
+// next returns the view resolving the dependencies of typ in owner.
+func (s *Scope) next(owner *Scope, typ reflect.Type) *Scope {
+	view := *owner
+	view.chain = append(append([]reflect.Type(nil), s.chain...), typ)
+	return &view
+}
+
+func (s *Scope) errorf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	if len(s.chain) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w, required by %v", err, formatChain(s.chain))
+}
+
+// Close closes the values created by the scope implementing io.Closer, in
+// reverse creation order.
+func (s *Scope) Close() error {
+	s.state.mu.Lock()
+	created := s.state.created
+	s.state.created = nil
+	s.state.instances = map[reflect.Type]*instance{}
+	s.state.mu.Unlock()
+
+	var errs []error
+	for i := len(created) - 1; i >= 0; i-- {
+		if closer, ok := created[i].(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type CycleError struct {
+	Chain []reflect.Type
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + formatChain(e.Chain)
+}
+
+func formatChain(chain []reflect.Type) string {
+	names := make([]string, 0, len(chain))
+	for _, typ := range chain {
+		names = append(names, typ.String())
+	}
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,190 @@
+package di
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type english struct {
+	name string
+}
+
+func (e *english) Greet() string {
+	return "hello " + e.name
+}
+
+type closer struct {
+	name   string
+	closed *[]string
+}
+
+func (c *closer) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return nil
+}
+
+type first struct{}
+type second struct{}
+
+func TestContainer_Resolve(t *testing.T) {
+	c := New()
+	created := 0
+	Value(c, "world")
+	Provide(c, Singleton, func(s *Scope) (*english, error) {
+		created++
+		name, err := Resolve[string](s)
+		return &english{name: name}, err
+	})
+	Bind[greeter, *english](c)
+	Provide(c, Scoped, func(s *Scope) (*int, error) {
+		v := 0
+		return &v, nil
+	})
+	require.NoError(t, c.Validate())
+
+	g, err := Resolve[greeter](c.Root())
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", g.Greet())
+
+	impl, err := Resolve[*english](c.NewScope())
+	require.NoError(t, err)
+	assert.Same(t, g, impl)
+	assert.Equal(t, 1, created)
+
+	scope, other := c.NewScope(), c.NewScope()
+	a, _ := Resolve[*int](scope)
+	b, _ := Resolve[*int](scope)
+	o, _ := Resolve[*int](other)
+	assert.Same(t, a, b)
+	assert.NotSame(t, a, o)
+
+	_, err = Resolve[*int](c.Root())
+	assert.ErrorContains(t, err, "scoped *int cannot be resolved outside of a scope")
+}
+
+func TestContainer_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(c *Container)
+		wantErr string
+	}{
+		{
+			name: "Should report cycles",
+			setup: func(c *Container) {
+				Provide(c, Singleton, func(s *Scope) (*first, error) {
+					_, err := Resolve[*second](s)
+					return &first{}, err
+				})
+				Provide(c, Singleton, func(s *Scope) (*second, error) {
+					_, err := Resolve[*first](s)
+					return &second{}, err
+				})
+			},
+			wantErr: "dependency cycle: *di.first -> *di.second -> *di.first",
+		},
+		{
+			name: "Should report missing providers",
+			setup: func(c *Container) {
+				Provide(c, Singleton, func(s *Scope) (*english, error) {
+					name, err := Resolve[string](s)
+					return &english{name: name}, err
+				})
+			},
+			wantErr: "no provider for string, required by *di.english",
+		},
+		{
+			name: "Should report singletons depending on scoped values",
+			setup: func(c *Container) {
+				Provide(c, Scoped, func(s *Scope) (string, error) { return "scoped", nil })
+				Provide(c, Singleton, func(s *Scope) (*english, error) {
+					name, err := Resolve[string](s)
+					return &english{name: name}, err
+				})
+			},
+			wantErr: "scoped string cannot be resolved outside of a scope, is a singleton depending on it?, required by *di.english",
+		},
+		{
+			name: "Should report types provided twice",
+			setup: func(c *Container) {
+				Value(c, "a")
+				Value(c, "b")
+			},
+			wantErr: "string is provided twice",
+		},
+		{
+			name: "Should report invalid bindings",
+			setup: func(c *Container) {
+				Value(c, "a")
+				Bind[greeter, string](c)
+			},
+			wantErr: "cannot bind di.greeter to string, it does not implement it",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			tt.setup(c)
+
+			err := c.Validate()
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestContainer_CycleError(t *testing.T) {
+	c := New()
+	Provide(c, Singleton, func(s *Scope) (*first, error) {
+		_, err := Resolve[*first](s)
+		return &first{}, err
+	})
+
+	_, err := Resolve[*first](c.Root())
+	var cycle *CycleError
+	require.True(t, errors.As(err, &cycle))
+	assert.Len(t, cycle.Chain, 2)
+}
+
+type scopedCloser struct {
+	closer
+}
+
+func TestScope_Close(t *testing.T) {
+	closed := make([]string, 0)
+	c := New()
+	Value(c, io.Closer(&closer{name: "external", closed: &closed}))
+	Provide(c, Singleton, func(s *Scope) (*closer, error) {
+		if _, err := Resolve[io.Closer](s); err != nil {
+			return nil, err
+		}
+		return &closer{name: "singleton", closed: &closed}, nil
+	})
+	Provide(c, Scoped, func(s *Scope) (*scopedCloser, error) {
+		return &scopedCloser{closer{name: "dependency", closed: &closed}}, nil
+	})
+	Provide(c, Scoped, func(s *Scope) (greeter, error) {
+		if _, err := Resolve[*scopedCloser](s); err != nil {
+			return nil, err
+		}
+		if _, err := Resolve[*closer](s); err != nil {
+			return nil, err
+		}
+		return &english{name: "scoped"}, nil
+	})
+
+	scope := c.NewScope()
+	_, err := Resolve[greeter](scope)
+	require.NoError(t, err)
+
+	require.NoError(t, scope.Close())
+	assert.Equal(t, []string{"dependency"}, closed)
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, []string{"dependency", "singleton"}, closed)
+}
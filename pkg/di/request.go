@@ -0,0 +1,28 @@
+package di
+
+import (
+	"context"
+	"github.com/yurikilian/bills/pkg/server"
+)
+
+type scopeKey struct{}
+
+func WithScope(ctx context.Context, scope *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+func FromContext(ctx context.Context) (*Scope, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(*Scope)
+	return scope, ok
+}
+
+// ResolveRequest resolves T in the scope of the request, see
+// middleware.RequestScope.
+func ResolveRequest[T any](ctx server.IHttpContext) (T, error) {
+	scope, ok := FromContext(ctx.ReqCtx())
+	if !ok {
+		var zero T
+		return zero, errNoRequestScope
+	}
+	return Resolve[T](scope)
+}
@@ -9,6 +9,10 @@ import (
 
 const baseUrl = "https://mybils.io"
 
+// StatusClientClosedRequest is the non standard status, borrowed from nginx,
+// of requests whose client went away before the response was written.
+const StatusClientClosedRequest = 499
+
 type Problem struct {
 	Code        int      `json:"code"`
 	Title       string   `json:"title"`
@@ -70,6 +74,106 @@ func NewUnsupportedMediaType(message string) Problem {
 	}
 }
 
+func NewUnauthorizedProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusUnauthorized,
+		Title:    "Unauthorized",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/unauthorized", baseUrl),
+	}
+}
+
+func NewForbiddenProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusForbidden,
+		Title:    "Forbidden",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/forbidden", baseUrl),
+	}
+}
+
+func NewConflictProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusConflict,
+		Title:    "Conflict",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/conflict", baseUrl),
+	}
+}
+
+func NewUnprocessableEntityProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusUnprocessableEntity,
+		Title:    "Unprocessable entity",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/unprocessable-entity", baseUrl),
+	}
+}
+
+func NewNotFoundProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusNotFound,
+		Title:    "Not found",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/not-found", baseUrl),
+	}
+}
+
+func NewPayloadTooLargeProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusRequestEntityTooLarge,
+		Title:    "Payload too large",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/payload-too-large", baseUrl),
+	}
+}
+
+func NewPreconditionFailedProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusPreconditionFailed,
+		Title:    "Precondition failed",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/precondition-failed", baseUrl),
+	}
+}
+
+func NewServiceUnavailableProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusServiceUnavailable,
+		Title:    "Service unavailable",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/service-unavailable", baseUrl),
+	}
+}
+
+func NewGatewayTimeoutProblem(message string) Problem {
+	return Problem{
+		Code:     http.StatusGatewayTimeout,
+		Title:    "Gateway timeout",
+		Message:  message,
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/gateway-timeout", baseUrl),
+	}
+}
+
+func NewClientClosedRequestProblem() Problem {
+	return Problem{
+		Code:     StatusClientClosedRequest,
+		Title:    "Client closed request",
+		Message:  "The client closed the request before the response was written",
+		Instance: "N/A",
+		Type:     fmt.Sprintf("%v/problems/client-closed-request", baseUrl),
+	}
+}
+
 func NewValidationProblem(vErrors []ValidationProblemDetail) Problem {
 	return Problem{
 		Code:        http.StatusBadRequest,
@@ -1,14 +1,15 @@
 package exception
 
 import (
-	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func AssertProblem(t *testing.T, expected Problem, actual Problem) {
-	assert.Equal(t, http.StatusBadRequest, actual.Code)
+func AssertProblem(t testing.TB, expected Problem, actual Problem) {
+	t.Helper()
+
+	assert.Equal(t, expected.Code, actual.Code)
 	assert.Equal(t, expected.Title, actual.Title)
 	assert.Equal(t, expected.Message, actual.Message)
 	assert.Equal(t, expected.Instance, actual.Instance)
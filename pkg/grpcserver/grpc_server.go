@@ -0,0 +1,190 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"net"
+	"sync/atomic"
+)
+
+// GrpcServer serves gRPC services next to a RestServer, sharing its
+// lifecycle through Attach. It implements grpc.ServiceRegistrar so the
+// generated Register functions can be used with it.
+type GrpcServer struct {
+	options  *Options
+	server   *grpc.Server
+	health   *health.Server
+	services []string
+	listener atomic.Pointer[net.Listener]
+	serveErr chan error
+}
+
+// NewGrpcServer chains the recovery, tracing, logging, problem and, when
+// configured, auth interceptors before the ones of the options. Streaming
+// calls get the same built-in interceptors.
+func NewGrpcServer(options *Options, serverOptions ...grpc.ServerOption) *GrpcServer {
+	interceptors := []grpc.UnaryServerInterceptor{
+		Recovery(options.Log),
+		Tracing(),
+		Logging(options.Log),
+		Problems(),
+	}
+	if options.Auth != nil {
+		interceptors = append(interceptors, Auth(options.Auth, options.Log))
+	}
+	interceptors = append(interceptors, options.Interceptors...)
+
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		StreamRecovery(options.Log),
+		StreamTracing(),
+		StreamLogging(options.Log),
+		StreamProblems(),
+	}
+	if options.Auth != nil {
+		streamInterceptors = append(streamInterceptors, StreamAuth(options.Auth, options.Log))
+	}
+
+	serverOptions = append(serverOptions,
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...))
+
+	srv := &GrpcServer{
+		options:  options,
+		server:   grpc.NewServer(serverOptions...),
+		health:   health.NewServer(),
+		serveErr: make(chan error, 1),
+	}
+	healthpb.RegisterHealthServer(srv.server, srv.health)
+	// Not serving until the process is ready, see Attach and Serve.
+	srv.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	if options.Reflection {
+		reflection.Register(srv.server)
+	}
+	return srv
+}
+
+// RegisterService registers a service, whose health status follows the server.
+func (srv *GrpcServer) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	srv.server.RegisterService(desc, impl)
+	srv.services = append(srv.services, desc.ServiceName)
+	srv.health.SetServingStatus(desc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Attach serves the server on a listener of the RestServer, so it is bound
+// and handed over on binary upgrades with the REST listeners, and drains it
+// within the RestServer grace period.
+func (srv *GrpcServer) Attach(rest *server.RestServer) *server.RestServer {
+	listener := server.ListenerOptions{Network: "tcp", Address: srv.options.BindAddress, Listener: srv.options.Listener}
+	return rest.
+		OnStart(srv.validate).
+		AttachListener(listener, srv.serve, srv.Shutdown).
+		OnReady(srv.ready).
+		OnStop(srv.notServing)
+}
+
+// Start binds the listener and serves in the background. Services are
+// reported as not serving until the server is marked ready, see Serve.
+func (srv *GrpcServer) Start(ctx context.Context) error {
+	if err := srv.validate(ctx); err != nil {
+		return err
+	}
+
+	listener := srv.options.Listener
+	if listener == nil {
+		var err error
+		if listener, err = (&net.ListenConfig{}).Listen(ctx, "tcp", srv.options.BindAddress); err != nil {
+			return err
+		}
+	}
+
+	srv.options.Log.Info(ctx, fmt.Sprintf("Starting gRPC server on %v address", listener.Addr()))
+	srv.listener.Store(&listener)
+	go func() {
+		if err := srv.serve(listener); err != nil {
+			srv.options.Log.Error(context.Background(), "gRPC server stopped serving: "+err.Error())
+			srv.serveErr <- err
+		}
+	}()
+	return nil
+}
+
+func (srv *GrpcServer) validate(context.Context) error {
+	return server.Validator.Validate(srv.options)
+}
+
+// serve blocks until the server is stopped, which is not reported as an error.
+func (srv *GrpcServer) serve(listener net.Listener) error {
+	srv.listener.Store(&listener)
+	if err := srv.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Serve is Start followed by marking the services as serving, for a gRPC
+// server running on its own.
+func (srv *GrpcServer) Serve(ctx context.Context) error {
+	if err := srv.Start(ctx); err != nil {
+		return err
+	}
+	return srv.ready(ctx)
+}
+
+func (srv *GrpcServer) ready(context.Context) error {
+	select {
+	case err := <-srv.serveErr:
+		return err
+	default:
+	}
+
+	srv.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	for _, service := range srv.services {
+		srv.health.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	return nil
+}
+
+// notServing reports every service as not serving as soon as a shutdown
+// starts, while in-flight calls are still served.
+func (srv *GrpcServer) notServing(context.Context) error {
+	srv.health.Shutdown()
+	return nil
+}
+
+// Shutdown reports every service as not serving and waits for in-flight calls
+// until ctx is done, then closes the remaining connections.
+func (srv *GrpcServer) Shutdown(ctx context.Context) error {
+	srv.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		srv.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// Addr returns the bound address, once started.
+func (srv *GrpcServer) Addr() net.Addr {
+	listener := srv.listener.Load()
+	if listener == nil {
+		return nil
+	}
+	return (*listener).Addr()
+}
+
+var _ grpc.ServiceRegistrar = (*GrpcServer)(nil)
@@ -0,0 +1,261 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/apikey"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+func dial(t *testing.T, listener *bufconn.Listener) *grpc.ClientConn {
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestGrpcServer_Lifecycle(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	srv := NewGrpcServer(NewGrpcServerOptions("", logger.NewProvider().ProvideLog()).WithListener(listener))
+	ctx := context.Background()
+
+	require.NoError(t, srv.Start(ctx))
+	health := healthpb.NewHealthClient(dial(t, listener))
+
+	resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	require.NoError(t, srv.ready(ctx))
+	resp, err = health.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	stream, err := reflectionpb.NewServerReflectionClient(dial(t, listener)).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	reflected, err := stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+	services := make([]string, 0)
+	for _, service := range reflected.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	assert.Contains(t, services, "grpc.health.v1.Health")
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.NoError(t, srv.Shutdown(shutdownCtx))
+}
+
+func TestGrpcServer_Attach(t *testing.T) {
+	log := logger.NewProvider().ProvideLog()
+	listener := bufconn.Listen(1 << 20)
+	srv := NewGrpcServer(NewGrpcServerOptions("", log).WithListener(listener))
+	rest := srv.Attach(server.NewRestServer(server.NewRestServerOptions("127.0.0.1:0", log).WithShutdown(0, time.Second)).
+		Router(server.NewRestRouter()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- rest.Run(ctx)
+	}()
+	<-rest.Ready()
+
+	health := healthpb.NewHealthClient(dial(t, listener))
+	require.Eventually(t, func() bool {
+		resp, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		return err == nil && resp.Status == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the servers did not stop after the context was cancelled")
+	}
+
+	_, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Error(t, err)
+}
+
+func TestGrpcServer_Options(t *testing.T) {
+	srv := NewGrpcServer(&Options{BindAddress: ":0"})
+
+	assert.ErrorContains(t, srv.Start(context.Background()), "Field validation for 'Log' failed on the 'required' tag")
+}
+
+func TestInterceptors(t *testing.T) {
+	log := logger.NewProvider().ProvideLog()
+	store := apikey.NewInMemoryStore()
+	rawKey, _, err := store.Issue(context.Background(), "ci", nil, time.Hour)
+	require.NoError(t, err)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/bills.transaction.v1.TransactionService/GetTransaction"}
+	interceptors := []grpc.UnaryServerInterceptor{Recovery(log), Tracing(), Logging(log), Problems(), Auth(NewAuthOptions(store), log)}
+
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		info     *grpc.UnaryServerInfo
+		handler  grpc.UnaryHandler
+		wantCode codes.Code
+	}{
+		{
+			name: "Should call the handler given a valid API key",
+			md:   metadata.Pairs("x-api-key", rawKey),
+			info: info,
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) {
+				if _, ok := apikey.FromContext(ctx); !ok {
+					t.Error("the key is not in the context")
+				}
+				return "ok", nil
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should reject calls without API key",
+			info:     info,
+			handler:  func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil },
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Should reject invalid API keys",
+			md:       metadata.Pairs("x-api-key", "bills_invalid"),
+			info:     info,
+			handler:  func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil },
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Should not authenticate public methods",
+			info:     &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"},
+			handler:  func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil },
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should recover from panics",
+			md:       metadata.Pairs("x-api-key", rawKey),
+			info:     info,
+			handler:  func(ctx context.Context, req interface{}) (interface{}, error) { panic("boom") },
+			wantCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+
+			_, err := chain(interceptors, tt.info, tt.handler)(ctx, nil)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+// chain calls the interceptors in order, as grpc.ChainUnaryInterceptor does.
+func chain(interceptors []grpc.UnaryServerInterceptor, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) grpc.UnaryHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return handler
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	log := logger.NewProvider().ProvideLog()
+	store := apikey.NewInMemoryStore()
+	rawKey, _, err := store.Issue(context.Background(), "ci", nil, time.Hour)
+	require.NoError(t, err)
+
+	info := &grpc.StreamServerInfo{FullMethod: "/bills.transaction.v1.TransactionService/WatchTransactions", IsServerStream: true}
+	interceptors := []grpc.StreamServerInterceptor{StreamRecovery(log), StreamTracing(), StreamLogging(log), StreamProblems(), StreamAuth(NewAuthOptions(store), log)}
+
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		info     *grpc.StreamServerInfo
+		handler  grpc.StreamHandler
+		wantCode codes.Code
+	}{
+		{
+			name: "Should call the handler given a valid API key",
+			md:   metadata.Pairs("x-api-key", rawKey),
+			info: info,
+			handler: func(srv interface{}, stream grpc.ServerStream) error {
+				if _, ok := apikey.FromContext(stream.Context()); !ok {
+					t.Error("the key is not in the stream context")
+				}
+				return nil
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should reject streams without API key",
+			info:     info,
+			handler:  func(srv interface{}, stream grpc.ServerStream) error { return nil },
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Should not authenticate public methods",
+			info:     &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"},
+			handler:  func(srv interface{}, stream grpc.ServerStream) error { return nil },
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Should convert problems to statuses",
+			md:       metadata.Pairs("x-api-key", rawKey),
+			info:     info,
+			handler:  func(srv interface{}, stream grpc.ServerStream) error { return exception.NewNotFoundProblem("missing") },
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "Should recover from panics",
+			md:       metadata.Pairs("x-api-key", rawKey),
+			info:     info,
+			handler:  func(srv interface{}, stream grpc.ServerStream) error { panic("boom") },
+			wantCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &contextStream{ctx: metadata.NewIncomingContext(context.Background(), tt.md)}
+
+			err := chainStream(interceptors, tt.info, tt.handler)(nil, stream)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+// chainStream calls the interceptors in order, as grpc.ChainStreamInterceptor does.
+func chainStream(interceptors []grpc.StreamServerInterceptor, info *grpc.StreamServerInfo, handler grpc.StreamHandler) grpc.StreamHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(srv interface{}, stream grpc.ServerStream) error {
+			return interceptor(srv, stream, info, next)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,238 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/apikey"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/logger"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Recovery turns a panicking handler into an internal error instead of
+// crashing the process.
+func Recovery(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Error(ctx, fmt.Sprintf("panic in %v: %v\n%s", info.FullMethod, p, debug.Stack()))
+				err = Status(exception.NewInternalServerError()).Err()
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery is the Recovery of streaming calls.
+func StreamRecovery(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Error(ss.Context(), fmt.Sprintf("panic in %v: %v\n%s", info.FullMethod, p, debug.Stack()))
+				err = Status(exception.NewInternalServerError()).Err()
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// Tracing starts a span per call, continuing the trace propagated in the
+// request metadata.
+func Tracing() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		endSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamTracing is the Tracing of streaming calls.
+func StreamTracing() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		endSpan(span, err)
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	}
+	return otel.Tracer("").Start(ctx, strings.TrimPrefix(fullMethod, "/"), trace.WithSpanKind(trace.SpanKindServer))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, Status(err).Message())
+	}
+}
+
+// Logging logs every call with its code and duration, failed calls as errors.
+func Logging(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamLogging is the Logging of streaming calls, logged once the stream ends.
+func StreamLogging(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+
+		logCall(ss.Context(), log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, log logger.Logger, fullMethod string, start time.Time, err error) {
+	code := Status(err).Code()
+	message := fmt.Sprintf("%v %v in %v", fullMethod, code, time.Since(start))
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.Unauthenticated, codes.PermissionDenied:
+		log.Info(ctx, message)
+	default:
+		log.Error(ctx, message+": "+err.Error())
+	}
+}
+
+// Problems converts the errors of the handlers, usually exception.Problem,
+// to gRPC statuses, see Status.
+func Problems() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, Status(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamProblems is the Problems of streaming calls.
+func StreamProblems() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return Status(err).Err()
+		}
+		return nil
+	}
+}
+
+// Auth verifies the API key of the request metadata, as the REST ApiKey
+// middleware does, and stores it in the context for apikey.FromContext.
+func Auth(options *AuthOptions, log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if options.isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, options, log)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth is the Auth of streaming calls. The key is stored in the stream
+// context.
+func StreamAuth(options *AuthOptions, log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if options.isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), options, log)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, options *AuthOptions, log logger.Logger) (context.Context, error) {
+	var rawKey string
+	if values := metadata.ValueFromIncomingContext(ctx, options.Metadata); len(values) > 0 {
+		rawKey = strings.TrimSpace(values[0])
+	}
+	if len(rawKey) == 0 {
+		return nil, exception.NewUnauthorizedProblem("An API key is required")
+	}
+
+	key, err := options.Store.Verify(ctx, rawKey)
+	if err != nil {
+		return nil, apiKeyProblem(err)
+	}
+
+	if err := options.Store.Touch(ctx, key); err != nil {
+		log.Warn(ctx, fmt.Sprintf("could not record api key %v usage: %v", key.Prefix, err))
+	}
+
+	return apikey.WithKey(ctx, key), nil
+}
+
+func apiKeyProblem(err error) error {
+	switch {
+	case errors.Is(err, apikey.ErrExpiredKey):
+		return exception.NewUnauthorizedProblem("The API key is expired")
+	case errors.Is(err, apikey.ErrRevokedKey):
+		return exception.NewUnauthorizedProblem("The API key was revoked")
+	case errors.Is(err, apikey.ErrMalformedKey), errors.Is(err, apikey.ErrInvalidKey):
+		return exception.NewUnauthorizedProblem("The API key is invalid")
+	default:
+		return exception.NewInternalServerError(err.Error())
+	}
+}
+
+// contextStream overrides the context of a stream, as unary interceptors do by
+// passing a new context to the handler.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,86 @@
+package grpcserver
+
+import (
+	"github.com/yurikilian/bills/pkg/apikey"
+	"github.com/yurikilian/bills/pkg/logger"
+	"google.golang.org/grpc"
+	"net"
+	"strings"
+)
+
+type Options struct {
+	BindAddress string `validate:"required_without=Listener"`
+	// Listener replaces BindAddress, e.g. with an in-memory listener in tests.
+	Listener net.Listener
+	Log      logger.Logger `validate:"required"`
+	Auth     *AuthOptions
+	// Reflection registers the server reflection service, used by tools such
+	// as grpcurl to discover the services.
+	Reflection bool
+	// Interceptors run after the built-in recovery, tracing, logging and auth
+	// interceptors.
+	Interceptors []grpc.UnaryServerInterceptor
+}
+
+func NewGrpcServerOptions(bindAddress string, log logger.Logger) *Options {
+	return &Options{
+		BindAddress: bindAddress,
+		Log:         log,
+		Reflection:  true,
+	}
+}
+
+func (o *Options) WithListener(listener net.Listener) *Options {
+	o.Listener = listener
+	return o
+}
+
+func (o *Options) WithAuth(auth *AuthOptions) *Options {
+	o.Auth = auth
+	return o
+}
+
+func (o *Options) WithoutReflection() *Options {
+	o.Reflection = false
+	return o
+}
+
+func (o *Options) WithInterceptor(interceptor grpc.UnaryServerInterceptor) *Options {
+	o.Interceptors = append(o.Interceptors, interceptor)
+	return o
+}
+
+type AuthOptions struct {
+	Store *apikey.Store
+	// Metadata is the key of the API key in the request metadata, lower case.
+	Metadata string
+	// Public methods, or services when ending with a slash, skip the
+	// authentication. Health and reflection are public by default.
+	Public []string
+}
+
+func NewAuthOptions(store *apikey.Store) *AuthOptions {
+	return &AuthOptions{
+		Store:    store,
+		Metadata: "x-api-key",
+		Public: []string{
+			"/grpc.health.v1.Health/",
+			"/grpc.reflection.v1alpha.ServerReflection/",
+			"/grpc.reflection.v1.ServerReflection/",
+		},
+	}
+}
+
+func (o *AuthOptions) WithPublic(methods ...string) *AuthOptions {
+	o.Public = append(o.Public, methods...)
+	return o
+}
+
+func (o *AuthOptions) isPublic(fullMethod string) bool {
+	for _, public := range o.Public {
+		if public == fullMethod || (strings.HasSuffix(public, "/") && strings.HasPrefix(fullMethod, public)) {
+			return true
+		}
+	}
+	return false
+}
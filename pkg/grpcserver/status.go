@@ -0,0 +1,95 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"github.com/yurikilian/bills/pkg/exception"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+const problemDomain = "mybils.io"
+
+// Status converts an error returned by a handler to a gRPC status. Problems
+// keep their title and type in an ErrorInfo detail and their field errors in
+// a BadRequest detail. Other errors are reported as internal, without their
+// message.
+func Status(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	var problem exception.Problem
+	switch {
+	case errors.As(err, &problem):
+		return problemStatus(problem)
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	default:
+		return problemStatus(exception.NewInternalServerError())
+	}
+}
+
+func problemStatus(problem exception.Problem) *status.Status {
+	message := problem.Message
+	if len(message) == 0 {
+		message = problem.Title
+	}
+	st := status.New(Code(problem.Code), message)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   problem.Title,
+		Domain:   problemDomain,
+		Metadata: map[string]string{"type": problem.Type, "instance": problem.Instance},
+	}
+	if len(problem.FieldErrors) == 0 {
+		if withDetails, err := st.WithDetails(info); err == nil {
+			return withDetails
+		}
+		return st
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, fieldError := range problem.FieldErrors {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{Description: fieldError})
+	}
+	if withDetails, err := st.WithDetails(info, badRequest); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// Code maps the HTTP status of a problem to the gRPC code with the same meaning.
+func Code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,89 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/pkg/exception"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	validation := exception.NewValidationProblem([]exception.ValidationProblemDetail{
+		exception.NewValidationProblemDetail("required", "Title", ""),
+	})
+
+	tests := []struct {
+		name            string
+		err             error
+		wantCode        codes.Code
+		wantMessage     string
+		wantFieldErrors []string
+	}{
+		{
+			name:            "Should map validation problems to invalid argument with field violations",
+			err:             validation,
+			wantCode:        codes.InvalidArgument,
+			wantMessage:     "The request does not satisfy the validation rules",
+			wantFieldErrors: []string{"Title is required"},
+		},
+		{
+			name:        "Should map wrapped problems",
+			err:         fmt.Errorf("find: %w", exception.NewNotFoundProblem("Transaction 1 not found")),
+			wantCode:    codes.NotFound,
+			wantMessage: "Transaction 1 not found",
+		},
+		{
+			name:        "Should map unauthorized problems",
+			err:         exception.NewUnauthorizedProblem("An API key is required"),
+			wantCode:    codes.Unauthenticated,
+			wantMessage: "An API key is required",
+		},
+		{
+			name:        "Should keep statuses",
+			err:         status.Error(codes.ResourceExhausted, "slow down"),
+			wantCode:    codes.ResourceExhausted,
+			wantMessage: "slow down",
+		},
+		{
+			name:        "Should map context errors",
+			err:         context.DeadlineExceeded,
+			wantCode:    codes.DeadlineExceeded,
+			wantMessage: "context deadline exceeded",
+		},
+		{
+			name:        "Should hide other errors",
+			err:         errors.New("pq: connection refused"),
+			wantCode:    codes.Internal,
+			wantMessage: "An undetermined error was triggered. Please, contact the support team",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := Status(tt.err)
+
+			assert.Equal(t, tt.wantCode, st.Code())
+			assert.Equal(t, tt.wantMessage, st.Message())
+
+			fieldErrors := make([]string, 0)
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					for _, violation := range badRequest.FieldViolations {
+						fieldErrors = append(fieldErrors, violation.Description)
+					}
+				}
+			}
+			if tt.wantFieldErrors != nil {
+				assert.Equal(t, tt.wantFieldErrors, fieldErrors)
+			} else {
+				assert.Empty(t, fieldErrors)
+			}
+		})
+	}
+
+	assert.Nil(t, Status(nil))
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+type CheckFunc func(ctx context.Context) (*Observation, error)
+
+// Observation carries the optional observed value of a check, such as a
+// response time, as defined by the health check draft.
+type Observation struct {
+	Value interface{}
+	Unit  string
+}
+
+type Check struct {
+	Name          string
+	ComponentType string
+	Timeout       time.Duration
+	CacheTTL      time.Duration
+	// Critical checks make the whole report fail. Others only downgrade it to warn.
+	Critical bool
+	run      CheckFunc
+
+	mu        sync.Mutex
+	cached    *Result
+	checkedAt time.Time
+}
+
+func NewCheck(name string, run CheckFunc) *Check {
+	return &Check{
+		Name:          name,
+		ComponentType: "component",
+		Timeout:       2 * time.Second,
+		Critical:      true,
+		run:           run,
+	}
+}
+
+func (c *Check) WithTimeout(timeout time.Duration) *Check {
+	c.Timeout = timeout
+	return c
+}
+
+func (c *Check) WithCache(ttl time.Duration) *Check {
+	c.CacheTTL = ttl
+	return c
+}
+
+func (c *Check) WithComponentType(componentType string) *Check {
+	c.ComponentType = componentType
+	return c
+}
+
+func (c *Check) NonCritical() *Check {
+	c.Critical = false
+	return c
+}
+
+type Result struct {
+	ComponentType string      `json:"componentType,omitempty"`
+	Status        Status      `json:"status"`
+	ObservedValue interface{} `json:"observedValue,omitempty"`
+	ObservedUnit  string      `json:"observedUnit,omitempty"`
+	Output        string      `json:"output,omitempty"`
+	Time          string      `json:"time"`
+}
+
+// Execute runs the check bounded by its timeout, reusing the last result while
+// it is younger than CacheTTL.
+func (c *Check) Execute(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.cached != nil && now.Sub(c.checkedAt) < c.CacheTTL {
+		return *c.cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	type outcome struct {
+		observation *Observation
+		err         error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		observation, err := c.run(checkCtx)
+		done <- outcome{observation: observation, err: err}
+	}()
+
+	result := Result{ComponentType: c.ComponentType, Status: Pass, Time: now.UTC().Format(time.RFC3339Nano)}
+
+	select {
+	case o := <-done:
+		if o.observation != nil {
+			result.ObservedValue = o.observation.Value
+			result.ObservedUnit = o.observation.Unit
+		}
+		if o.err != nil {
+			result.Status = c.failureStatus()
+			result.Output = o.err.Error()
+		}
+	case <-checkCtx.Done():
+		result.Status = c.failureStatus()
+		result.Output = "check timed out after " + c.Timeout.String()
+	}
+
+	c.cached = &result
+	c.checkedAt = now
+	return result
+}
+
+func (c *Check) failureStatus() Status {
+	if c.Critical {
+		return Fail
+	}
+	return Warn
+}
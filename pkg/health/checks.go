@@ -0,0 +1,17 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+func PostgresCheck(db *sql.DB) *Check {
+	return NewCheck("postgres:responseTime", func(ctx context.Context) (*Observation, error) {
+		started := time.Now()
+		if err := db.PingContext(ctx); err != nil {
+			return nil, err
+		}
+		return &Observation{Value: time.Since(started).Milliseconds(), Unit: "ms"}, nil
+	}).WithComponentType("datastore")
+}
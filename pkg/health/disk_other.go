@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package health
+
+import (
+	"context"
+	"errors"
+)
+
+func DiskSpaceCheck(path string, minFreeBytes uint64) *Check {
+	return NewCheck("disk:utilization", func(ctx context.Context) (*Observation, error) {
+		return nil, errors.New("disk space check is not supported on this platform")
+	}).WithComponentType("system").NonCritical()
+}
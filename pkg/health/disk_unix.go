@@ -0,0 +1,27 @@
+//go:build linux || darwin
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// DiskSpaceCheck fails when the filesystem holding path has less than
+// minFreeBytes available to unprivileged users.
+func DiskSpaceCheck(path string, minFreeBytes uint64) *Check {
+	return NewCheck("disk:utilization", func(ctx context.Context) (*Observation, error) {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return nil, err
+		}
+
+		free := uint64(stat.Bavail) * uint64(stat.Bsize)
+		observation := &Observation{Value: free, Unit: "bytes"}
+		if free < minFreeBytes {
+			return observation, fmt.Errorf("only %v bytes free on %v", free, path)
+		}
+		return observation, nil
+	}).WithComponentType("system")
+}
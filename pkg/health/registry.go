@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const contentType = "application/health+json"
+
+// Report follows the "Health Check Response Format for HTTP APIs" draft.
+type Report struct {
+	Status      Status              `json:"status"`
+	Version     string              `json:"version,omitempty"`
+	ReleaseId   string              `json:"releaseId,omitempty"`
+	ServiceId   string              `json:"serviceId,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Output      string              `json:"output,omitempty"`
+	Checks      map[string][]Result `json:"checks,omitempty"`
+}
+
+type Registry struct {
+	Version   string
+	ReleaseId string
+	ServiceId string
+
+	mu        sync.RWMutex
+	liveness  []*Check
+	readiness []*Check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Liveness checks must only fail when restarting the process would help.
+func (r *Registry) Liveness(check *Check) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.liveness = append(r.liveness, check)
+	return r
+}
+
+// Readiness checks decide whether the instance should receive traffic.
+func (r *Registry) Readiness(check *Check) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.readiness = append(r.readiness, check)
+	return r
+}
+
+// Mount registers /health/live and /health/ready on the server router. The
+// readiness report fails while the server is not accepting traffic.
+func (r *Registry) Mount(srv *server.RestServer) *server.RestServer {
+	srv.RestRouter().
+		Get("/health/live", func(ctx server.IHttpContext) error {
+			return writeReport(ctx, r.report(ctx.ReqCtx(), r.checks(false), nil))
+		}).
+		Get("/health/ready", func(ctx server.IHttpContext) error {
+			var notReady *Result
+			if !srv.IsReady() {
+				notReady = &Result{ComponentType: "system", Status: Fail, Output: "server is not accepting traffic",
+					Time: time.Now().UTC().Format(time.RFC3339Nano)}
+			}
+			return writeReport(ctx, r.report(ctx.ReqCtx(), r.checks(true), notReady))
+		})
+	return srv
+}
+
+func (r *Registry) checks(readiness bool) []*Check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if readiness {
+		return append([]*Check(nil), r.readiness...)
+	}
+	return append([]*Check(nil), r.liveness...)
+}
+
+func (r *Registry) report(ctx context.Context, checks []*Check, serverResult *Result) *Report {
+	report := &Report{
+		Status:    Pass,
+		Version:   r.Version,
+		ReleaseId: r.ReleaseId,
+		ServiceId: r.ServiceId,
+		Checks:    map[string][]Result{},
+	}
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *Check) {
+			defer wg.Done()
+			results[i] = check.Execute(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for i, check := range checks {
+		report.Checks[check.Name] = append(report.Checks[check.Name], results[i])
+		report.Status = worst(report.Status, results[i].Status)
+	}
+
+	if serverResult != nil {
+		report.Checks["server:readiness"] = []Result{*serverResult}
+		report.Status = worst(report.Status, serverResult.Status)
+	}
+	return report
+}
+
+func writeReport(ctx server.IHttpContext, report *Report) error {
+	statusCode := http.StatusOK
+	if report.Status == Fail {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	ctx.Writer().Header().Set("Cache-Control", "no-store")
+	ctx.Writer().Header().Set("Content-Type", contentType)
+	return ctx.WriteResponse(statusCode, report)
+}
+
+func worst(current Status, other Status) Status {
+	if current == Fail || other == Fail {
+		return Fail
+	}
+	if current == Warn || other == Warn {
+		return Warn
+	}
+	return Pass
+}
@@ -0,0 +1,92 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Mount(t *testing.T) {
+	failing := func(ctx context.Context) (*Observation, error) { return nil, errors.New("connection refused") }
+	passing := func(ctx context.Context) (*Observation, error) { return &Observation{Value: 3, Unit: "ms"}, nil }
+	slow := func(ctx context.Context) (*Observation, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		checks     []*Check
+		wantStatus int
+		wantReport Status
+	}{
+		{
+			name:       "Should pass liveness without checks",
+			path:       "/health/live",
+			wantStatus: http.StatusOK,
+			wantReport: Pass,
+		},
+		{
+			name:       "Should fail readiness while the server is not started",
+			path:       "/health/ready",
+			checks:     []*Check{NewCheck("postgres:responseTime", passing)},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: Fail,
+		},
+		{
+			name:       "Should warn when a non critical check fails",
+			path:       "/health/live",
+			checks:     []*Check{NewCheck("cache", failing).NonCritical()},
+			wantStatus: http.StatusOK,
+			wantReport: Warn,
+		},
+		{
+			name:       "Should fail when a check times out",
+			path:       "/health/live",
+			checks:     []*Check{NewCheck("slow", slow).WithTimeout(10 * time.Millisecond)},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: Fail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, check := range tt.checks {
+				registry.Liveness(check).Readiness(check)
+			}
+			srv := registry.Mount(server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())))
+
+			recorder := httptest.NewRecorder()
+			srv.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			var report Report
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			assert.Equal(t, "application/health+json", recorder.Header().Get("Content-Type"))
+			assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+			assert.Equal(t, tt.wantReport, report.Status)
+		})
+	}
+}
+
+func TestCheck_Execute_Cache(t *testing.T) {
+	calls := 0
+	check := NewCheck("counter", func(ctx context.Context) (*Observation, error) {
+		calls++
+		return nil, nil
+	}).WithCache(time.Minute)
+
+	check.Execute(context.Background())
+	result := check.Execute(context.Background())
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, Pass, result.Status)
+}
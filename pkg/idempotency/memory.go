@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval bounds how often Begin scans the records for expired ones.
+const sweepInterval = time.Minute
+
+type InMemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*Record
+	now       func() time.Time
+	nextSweep time.Time
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		records: map[string]*Record{},
+		now:     time.Now,
+	}
+}
+
+func (s *InMemoryStore) Begin(_ context.Context, key string, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) {
+		record := *existing
+		return &record, false, nil
+	}
+
+	record := &Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      InFlight,
+		ExpiresAt:   now.Add(ttl),
+	}
+	s.records[key] = record
+
+	created := *record
+	return &created, true, nil
+}
+
+func (s *InMemoryStore) Complete(_ context.Context, key string, response *Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		record.Status = Completed
+		record.Response = response
+	}
+	return nil
+}
+
+func (s *InMemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep evicts the expired records, at most once per sweepInterval.
+func (s *InMemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(sweepInterval)
+
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
+
+var _ Store = (*InMemoryStore)(nil)
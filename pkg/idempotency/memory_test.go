@@ -0,0 +1,67 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	store := NewInMemoryStore()
+	store.now = func() time.Time { return now }
+
+	record, created, err := store.Begin(ctx, "a", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, InFlight, record.Status)
+
+	record, created, err = store.Begin(ctx, "a", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, InFlight, record.Status)
+
+	response := &Response{StatusCode: http.StatusCreated, Body: []byte("1")}
+	require.NoError(t, store.Complete(ctx, "a", response))
+	record, created, err = store.Begin(ctx, "a", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, Completed, record.Status)
+	assert.Equal(t, response, record.Response)
+
+	_, _, err = store.Begin(ctx, "b", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.Release(ctx, "b"))
+	_, created, err = store.Begin(ctx, "b", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, created, "a released key can be used again")
+
+	now = now.Add(2 * time.Minute)
+	_, created, err = store.Begin(ctx, "a", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, created, "an expired key can be used again")
+}
+
+func TestInMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	store := NewInMemoryStore()
+	store.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, err := store.Begin(ctx, key, "", time.Second)
+		require.NoError(t, err)
+	}
+	assert.Len(t, store.records, 3)
+
+	now = now.Add(sweepInterval)
+	_, _, err := store.Begin(ctx, "d", "", time.Hour)
+	require.NoError(t, err)
+
+	assert.Len(t, store.records, 1)
+	assert.Contains(t, store.records, "d")
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PsqlSchema creates the table used by PsqlStore.
+const PsqlSchema = `CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key         TEXT PRIMARY KEY,
+	fingerprint TEXT NOT NULL,
+	status      INTEGER NOT NULL,
+	status_code INTEGER,
+	headers     TEXT,
+	body        BYTEA,
+	expires_at  BIGINT NOT NULL
+)`
+
+type PsqlStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewPsqlStore(db *sql.DB) *PsqlStore {
+	return &PsqlStore{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+func (s *PsqlStore) Begin(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	now := s.now()
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1 AND expires_at <= $2", key, now.UnixNano()); err != nil {
+		return nil, false, fmt.Errorf("could not expire idempotency key: %w", err)
+	}
+
+	expiresAt := now.Add(ttl)
+	result, err := s.db.ExecContext(ctx,
+		"INSERT INTO idempotency_keys(key, fingerprint, status, expires_at) VALUES($1, $2, $3, $4) ON CONFLICT (key) DO NOTHING",
+		key, fingerprint, InFlight, expiresAt.UnixNano())
+	if err != nil {
+		return nil, false, fmt.Errorf("could not reserve idempotency key: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 1 {
+		return &Record{Key: key, Fingerprint: fingerprint, Status: InFlight, ExpiresAt: expiresAt}, true, nil
+	}
+
+	record, err := s.find(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	return record, false, nil
+}
+
+func (s *PsqlStore) Complete(ctx context.Context, key string, response *Response) error {
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status = $1, status_code = $2, headers = $3, body = $4 WHERE key = $5",
+		Completed, response.StatusCode, string(headers), response.Body, key)
+	if err != nil {
+		return fmt.Errorf("could not store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (s *PsqlStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1", key)
+	if err != nil {
+		return fmt.Errorf("could not release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *PsqlStore) find(ctx context.Context, key string) (*Record, error) {
+	var (
+		record     Record
+		statusCode sql.NullInt64
+		headers    sql.NullString
+		body       []byte
+		expiresAt  int64
+	)
+
+	row := s.db.QueryRowContext(ctx,
+		"SELECT key, fingerprint, status, status_code, headers, body, expires_at FROM idempotency_keys WHERE key = $1", key)
+	err := row.Scan(&record.Key, &record.Fingerprint, &record.Status, &statusCode, &headers, &body, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released between the insert attempt and this read; report it as
+		// in flight so the client retries.
+		return &Record{Key: key, Status: InFlight}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record.ExpiresAt = time.Unix(0, expiresAt)
+	if record.Status == Completed {
+		record.Response = &Response{StatusCode: int(statusCode.Int64), Body: body}
+		if headers.Valid {
+			if err := json.Unmarshal([]byte(headers.String), &record.Response.Header); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &record, nil
+}
+
+var _ Store = (*PsqlStore)(nil)
@@ -0,0 +1,37 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type Status int
+
+const (
+	InFlight Status = iota
+	Completed
+)
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type Record struct {
+	Key         string
+	Fingerprint string
+	Status      Status
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Store keeps the first response of each idempotency key. Begin must be atomic:
+// only one caller may receive created = true for a key until it is released or
+// expires.
+type Store interface {
+	Begin(ctx context.Context, key string, fingerprint string, ttl time.Duration) (record *Record, created bool, err error)
+	Complete(ctx context.Context, key string, response *Response) error
+	Release(ctx context.Context, key string) error
+}
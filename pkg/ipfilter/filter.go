@@ -0,0 +1,162 @@
+package ipfilter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/logger"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Rules struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// Allows applies deny rules first. A non-empty allow list rejects every
+// address it does not contain.
+func (r *Rules) Allows(addr netip.Addr) bool {
+	for _, prefix := range r.Deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	if len(r.Allow) == 0 {
+		return true
+	}
+
+	for _, prefix := range r.Allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRules reads one rule per line in the "allow <cidr>" or "deny <cidr>"
+// format. Empty lines and lines starting with # are ignored.
+func ParseRules(reader io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	scanner := bufio.NewScanner(reader)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %v: expected \"allow|deny <cidr>\"", line)
+		}
+
+		prefix, err := ParsePrefix(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", line, err)
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "allow":
+			rules.Allow = append(rules.Allow, prefix)
+		case "deny":
+			rules.Deny = append(rules.Deny, prefix)
+		default:
+			return nil, fmt.Errorf("line %v: unknown action %v", line, fields[0])
+		}
+	}
+
+	return rules, scanner.Err()
+}
+
+// ParsePrefix accepts CIDRs and single addresses, which are turned into a
+// host prefix.
+func ParsePrefix(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+type Filter struct {
+	rules atomic.Pointer[Rules]
+}
+
+func NewFilter(rules *Rules) *Filter {
+	filter := &Filter{}
+	filter.rules.Store(rules)
+	return filter
+}
+
+func NewFilterFromFile(path string) (*Filter, error) {
+	rules, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFilter(rules), nil
+}
+
+func (f *Filter) Allows(addr netip.Addr) bool {
+	return f.rules.Load().Allows(addr)
+}
+
+func (f *Filter) SetRules(rules *Rules) {
+	f.rules.Store(rules)
+}
+
+// Watch reloads the rules whenever the file modification time changes, until
+// ctx is cancelled. Invalid files are logged and the previous rules are kept.
+func (f *Filter) Watch(ctx context.Context, path string, interval time.Duration, log logger.Logger) {
+	var lastModified time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastModified = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().After(lastModified) {
+			continue
+		}
+		lastModified = info.ModTime()
+
+		rules, err := loadFile(path)
+		if err != nil {
+			log.Error(ctx, fmt.Sprintf("could not reload ip filter rules from %v: %v", path, err))
+			continue
+		}
+
+		f.SetRules(rules)
+		log.Info(ctx, fmt.Sprintf("reloaded ip filter rules from %v", path))
+	}
+}
+
+func loadFile(path string) (*Rules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseRules(file)
+}
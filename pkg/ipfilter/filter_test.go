@@ -0,0 +1,92 @@
+package ipfilter
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRules_Allows(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`
+# office and vpn
+allow 10.0.0.0/8
+allow 2001:db8::/32
+deny 10.0.5.0/24
+deny 10.0.0.7
+`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		addr     string
+		expected bool
+	}{
+		{name: "Should allow address inside allow list", addr: "10.1.2.3", expected: true},
+		{name: "Should allow ipv6 address inside allow list", addr: "2001:db8::5", expected: true},
+		{name: "Should deny address inside deny cidr", addr: "10.0.5.9", expected: false},
+		{name: "Should deny single denied address", addr: "10.0.0.7", expected: false},
+		{name: "Should deny address outside allow list", addr: "203.0.113.7", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rules.Allows(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	_, err := ParseRules(strings.NewReader("permit 10.0.0.0/8"))
+	assert.EqualError(t, err, "line 1: unknown action permit")
+
+	_, err = ParseRules(strings.NewReader("allow 10.0.0.0/33"))
+	assert.Error(t, err)
+}
+
+func TestFilter_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules")
+	assert.NoError(t, os.WriteFile(path, []byte("allow 10.0.0.0/8\n"), 0o600))
+
+	filter, err := NewFilterFromFile(path)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		filter.Watch(ctx, path, 5*time.Millisecond, logger.NewProvider().ProvideLog())
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	office := netip.MustParseAddr("10.1.2.3")
+	public := netip.MustParseAddr("203.0.113.7")
+	assert.True(t, filter.Allows(office))
+	assert.False(t, filter.Allows(public))
+
+	// The watcher may take its first stat after any write, so every attempt
+	// moves the modification time forward.
+	modified := time.Now()
+	assert.Eventually(t, func() bool {
+		modified = modified.Add(time.Second)
+		writeRules(t, path, "allow 203.0.113.0/24\n", modified)
+		return filter.Allows(public) && !filter.Allows(office)
+	}, time.Second, 10*time.Millisecond)
+
+	writeRules(t, path, "permit 10.0.0.0/8\n", modified.Add(time.Second))
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, filter.Allows(public), "invalid rules should keep the previous ones")
+	assert.False(t, filter.Allows(office), "invalid rules should keep the previous ones")
+}
+
+func writeRules(t *testing.T, path string, rules string, modified time.Time) {
+	assert.NoError(t, os.WriteFile(path, []byte(rules), 0o600))
+	assert.NoError(t, os.Chtimes(path, modified, modified))
+}
@@ -0,0 +1,321 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"sync"
+)
+
+const version = "2.0"
+
+// Error codes defined by the JSON-RPC 2.0 specification.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+	// ServerError is used for the other problems, whose HTTP code is kept in
+	// the error data.
+	ServerError = -32000
+)
+
+type Request struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Id      json.RawMessage `json:"id,omitempty"`
+}
+
+type Response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+}
+
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type method func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+type Options struct {
+	// MaxBodyBytes limits the size of a request or batch.
+	MaxBodyBytes int64 `validate:"gt=0"`
+	// MaxBatchSize limits the number of calls of a batch.
+	MaxBatchSize int `validate:"gt=0"`
+	// BatchConcurrency limits the calls of a batch running at once, one runs
+	// them sequentially.
+	BatchConcurrency int `validate:"gt=0"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		MaxBodyBytes:     1 << 20,
+		MaxBatchSize:     100,
+		BatchConcurrency: 4,
+	}
+}
+
+// Endpoint dispatches JSON-RPC 2.0 calls to the registered methods.
+type Endpoint struct {
+	options *Options
+	methods map[string]method
+}
+
+func NewEndpoint(options *Options) *Endpoint {
+	return &Endpoint{
+		options: options,
+		methods: map[string]method{},
+	}
+}
+
+// Register adds a method whose params are decoded into P, by name or as the
+// single element of a positional array, and validated like request bodies.
+// Problems returned by fn are converted to JSON-RPC errors.
+func Register[P any, R any](e *Endpoint, name string, fn func(ctx context.Context, params P) (R, error)) *Endpoint {
+	e.methods[name] = func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var params P
+		if err := decodeParams(raw, &params); err != nil {
+			return nil, &Error{Code: InvalidParams, Message: "Invalid params", Data: err.Error()}
+		}
+
+		if err := validate(params); err != nil {
+			return nil, err
+		}
+
+		return fn(ctx, params)
+	}
+	return e
+}
+
+// Mount registers the endpoint on POST path.
+func (e *Endpoint) Mount(router *server.RestRouter, path string) *server.RestRouter {
+	return router.POST(path, e.Handle)
+}
+
+func (e *Endpoint) Handle(ctx server.IHttpContext) error {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request().Body, e.options.MaxBodyBytes+1))
+	if err != nil {
+		return exception.NewBadRequestProblem(err.Error())
+	}
+	if int64(len(body)) > e.options.MaxBodyBytes {
+		return ctx.WriteResponse(http.StatusOK, errorResponse(nil, &Error{Code: InvalidRequest, Message: "Request too large"}))
+	}
+
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
+		return ctx.WriteResponse(http.StatusOK, errorResponse(nil, &Error{Code: ParseError, Message: "Parse error"}))
+	}
+
+	if len(body) == 0 || body[0] != '[' {
+		response := e.call(ctx.ReqCtx(), ctx.Logger(), body)
+		if response == nil {
+			return ctx.WriteResponse(http.StatusNoContent, nil)
+		}
+		return ctx.WriteResponse(http.StatusOK, response)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		return ctx.WriteResponse(http.StatusOK, errorResponse(nil, &Error{Code: InvalidRequest, Message: "Invalid Request"}))
+	}
+	if len(batch) > e.options.MaxBatchSize {
+		message := fmt.Sprintf("Batch of %v calls exceeds the limit of %v", len(batch), e.options.MaxBatchSize)
+		return ctx.WriteResponse(http.StatusOK, errorResponse(nil, &Error{Code: InvalidRequest, Message: message}))
+	}
+
+	// At most BatchConcurrency calls of a batch run at once, responses keep
+	// the call order.
+	responses := make([]*Response, len(batch))
+	concurrency := e.options.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, raw := range batch {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, raw json.RawMessage) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			responses[i] = e.call(ctx.ReqCtx(), ctx.Logger(), raw)
+		}(i, raw)
+	}
+	wg.Wait()
+
+	results := make([]*Response, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			results = append(results, response)
+		}
+	}
+	if len(results) == 0 {
+		return ctx.WriteResponse(http.StatusNoContent, nil)
+	}
+	return ctx.WriteResponse(http.StatusOK, results)
+}
+
+// call runs a single call, it returns nil for notifications.
+func (e *Endpoint) call(ctx context.Context, log logger.Logger, raw json.RawMessage) *Response {
+	var request Request
+	if err := json.Unmarshal(raw, &request); err != nil || request.JsonRpc != version || len(request.Method) == 0 || !validId(request.Id) {
+		return errorResponse(nil, &Error{Code: InvalidRequest, Message: "Invalid Request"})
+	}
+
+	notification := request.Id == nil
+	result, err := e.invoke(ctx, log, request)
+	if notification {
+		if err != nil {
+			log.Debug(ctx, fmt.Sprintf("JSON-RPC notification %v failed: %v", request.Method, err))
+		}
+		return nil
+	}
+
+	if err != nil {
+		return errorResponse(request.Id, toError(ctx, log, request.Method, err))
+	}
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	return &Response{JsonRpc: version, Result: result, Id: request.Id}
+}
+
+func (e *Endpoint) invoke(ctx context.Context, log logger.Logger, request Request) (result interface{}, err error) {
+	m, ok := e.methods[request.Method]
+	if !ok {
+		return nil, &Error{Code: MethodNotFound, Message: "Method not found", Data: request.Method}
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			log.Error(ctx, fmt.Sprintf("panic in JSON-RPC method %v: %v\n%s", request.Method, p, debug.Stack()))
+			err = &Error{Code: InternalError, Message: "Internal error"}
+		}
+	}()
+	return m(ctx, request.Params)
+}
+
+// toError maps problems to JSON-RPC errors, keeping the problem as data.
+// Other errors are reported as internal, without their message.
+func toError(ctx context.Context, log logger.Logger, method string, err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	var problem exception.Problem
+	if !errors.As(err, &problem) {
+		log.Error(ctx, fmt.Sprintf("JSON-RPC method %v failed: %v", method, err))
+		return &Error{Code: InternalError, Message: "Internal error"}
+	}
+
+	message := problem.Message
+	if len(message) == 0 {
+		message = problem.Title
+	}
+
+	switch problem.Code {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return &Error{Code: InvalidParams, Message: message, Data: problem}
+	case http.StatusInternalServerError:
+		log.Error(ctx, fmt.Sprintf("JSON-RPC method %v failed: %v", method, err))
+		return &Error{Code: InternalError, Message: "Internal error"}
+	default:
+		return &Error{Code: ServerError, Message: message, Data: problem}
+	}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Response{JsonRpc: version, Error: err, Id: id}
+}
+
+// validId accepts the string, number and null ids of the specification.
+func validId(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+func decodeParams(raw json.RawMessage, params interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	if raw[0] == '[' && !isSequence(reflect.TypeOf(params).Elem()) {
+		var positional []json.RawMessage
+		if err := json.Unmarshal(raw, &positional); err != nil {
+			return err
+		}
+		switch len(positional) {
+		case 0:
+			return nil
+		case 1:
+			raw = positional[0]
+		default:
+			return fmt.Errorf("expected a single positional param, got %v", len(positional))
+		}
+	}
+	return json.Unmarshal(raw, params)
+}
+
+func isSequence(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array
+}
+
+// validate applies the validation tags of struct params, the same way request
+// bodies are validated.
+func validate(params interface{}) error {
+	typ := reflect.TypeOf(params)
+	if typ == nil {
+		return nil
+	}
+	if typ.Kind() == reflect.Pointer {
+		if reflect.ValueOf(params).IsNil() {
+			return nil
+		}
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if err := server.Validator.Validate(params); err != nil {
+		var vErrors validator.ValidationErrors
+		if !errors.As(err, &vErrors) {
+			return exception.NewBadRequestProblem(err.Error())
+		}
+		return exception.NewValidationProblem(server.Validator.MapValidationProblems(err))
+	}
+	return nil
+}
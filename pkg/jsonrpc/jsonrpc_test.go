@@ -0,0 +1,209 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"github.com/yurikilian/bills/pkg/server/servertest"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sumParams struct {
+	A int `json:"a" validate:"required"`
+	B int `json:"b"`
+}
+
+func newServer() *server.RestServer {
+	endpoint := NewEndpoint(NewOptions())
+	Register(endpoint, "sum", func(ctx context.Context, params sumParams) (int, error) {
+		return params.A + params.B, nil
+	})
+	Register(endpoint, "forbidden", func(ctx context.Context, params struct{}) (interface{}, error) {
+		return nil, exception.NewForbiddenProblem("Not allowed")
+	})
+	Register(endpoint, "fail", func(ctx context.Context, params []string) (interface{}, error) {
+		return nil, errors.New("pq: connection refused")
+	})
+	Register(endpoint, "panic", func(ctx context.Context, params interface{}) (interface{}, error) {
+		panic("boom")
+	})
+
+	return server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(endpoint.Mount(server.NewRestRouter(), "/rpc"))
+}
+
+func TestEndpoint_Handle(t *testing.T) {
+	client := servertest.New(t, newServer())
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantJSON   map[string]interface{}
+	}{
+		{
+			name:       "Should call methods with named params",
+			body:       `{"jsonrpc":"2.0","method":"sum","params":{"a":1,"b":2},"id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"result": 3.0, "id": 1.0},
+		},
+		{
+			name:       "Should call methods with a positional param",
+			body:       `{"jsonrpc":"2.0","method":"sum","params":[{"a":1,"b":2}],"id":"a"}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"result": 3.0, "id": "a"},
+		},
+		{
+			name:       "Should report validation errors as invalid params",
+			body:       `{"jsonrpc":"2.0","method":"sum","params":{"b":2},"id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON: map[string]interface{}{
+				"error.code":             -32602.0,
+				"error.data.fieldErrors": []interface{}{"A is required"},
+			},
+		},
+		{
+			name:       "Should report undecodable params as invalid params",
+			body:       `{"jsonrpc":"2.0","method":"sum","params":{"a":"one"},"id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32602.0, "error.message": "Invalid params"},
+		},
+		{
+			name:       "Should report unknown methods",
+			body:       `{"jsonrpc":"2.0","method":"divide","id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32601.0, "error.data": "divide"},
+		},
+		{
+			name:       "Should map other problems to server errors",
+			body:       `{"jsonrpc":"2.0","method":"forbidden","id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32000.0, "error.message": "Not allowed", "error.data.code": 403.0},
+		},
+		{
+			name:       "Should hide unexpected errors",
+			body:       `{"jsonrpc":"2.0","method":"fail","id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32603.0, "error.message": "Internal error"},
+		},
+		{
+			name:       "Should recover from panics",
+			body:       `{"jsonrpc":"2.0","method":"panic","id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32603.0},
+		},
+		{
+			name:       "Should report parse errors",
+			body:       `{"jsonrpc":"2.0","method":`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32700.0, "id": nil},
+		},
+		{
+			name:       "Should report invalid requests",
+			body:       `{"jsonrpc":"1.0","method":"sum","id":1}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32600.0, "id": nil},
+		},
+		{
+			name:       "Should report empty batches as invalid requests",
+			body:       `[]`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"error.code": -32600.0},
+		},
+		{
+			name:       "Should answer requests with a null id",
+			body:       `{"jsonrpc":"2.0","method":"sum","params":{"a":1},"id":null}`,
+			wantStatus: http.StatusOK,
+			wantJSON:   map[string]interface{}{"result": 1.0, "id": nil},
+		},
+		{
+			name:       "Should not answer notifications",
+			body:       `{"jsonrpc":"2.0","method":"sum","params":{"a":1}}`,
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Post("/rpc").WithBody("application/json", []byte(tt.body)).Do().
+				AssertStatus(tt.wantStatus)
+
+			for path, want := range tt.wantJSON {
+				resp.AssertJSONPath(path, want)
+			}
+		})
+	}
+}
+
+func TestEndpoint_Handle_Batch(t *testing.T) {
+	client := servertest.New(t, newServer())
+
+	var responses []Response
+	client.Post("/rpc").WithBody("application/json", []byte(`[
+		{"jsonrpc":"2.0","method":"sum","params":{"a":1,"b":1},"id":1},
+		{"jsonrpc":"2.0","method":"sum","params":{"a":5}},
+		{"jsonrpc":"2.0","method":"divide","id":2},
+		1,
+		{"jsonrpc":"2.0","method":"sum","params":{"a":2,"b":2},"id":3}
+	]`)).Do().
+		AssertStatus(http.StatusOK).
+		Decode(&responses)
+
+	assert.Len(t, responses, 4)
+	assert.Equal(t, `1`, string(responses[0].Id))
+	assert.Equal(t, 2.0, responses[0].Result)
+	assert.Equal(t, MethodNotFound, responses[1].Error.Code)
+	assert.Equal(t, InvalidRequest, responses[2].Error.Code)
+	assert.Equal(t, `null`, string(responses[2].Id))
+	assert.Equal(t, 4.0, responses[3].Result)
+
+	client.Post("/rpc").WithBody("application/json", []byte(`[{"jsonrpc":"2.0","method":"sum","params":{"a":5}}]`)).Do().
+		AssertStatus(http.StatusNoContent)
+}
+
+func TestEndpoint_Handle_BatchConcurrency(t *testing.T) {
+	options := NewOptions()
+	options.BatchConcurrency = 2
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	endpoint := NewEndpoint(options)
+	Register(endpoint, "slow", func(ctx context.Context, params struct{}) (int, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return 1, nil
+	})
+	client := servertest.New(t, server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(endpoint.Mount(server.NewRestRouter(), "/rpc")))
+
+	batch := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		batch = append(batch, fmt.Sprintf(`{"jsonrpc":"2.0","method":"slow","id":%d}`, i))
+	}
+
+	var responses []Response
+	client.Post("/rpc").WithBody("application/json", []byte("["+strings.Join(batch, ",")+"]")).Do().
+		AssertStatus(http.StatusOK).
+		Decode(&responses)
+
+	assert.Len(t, responses, 8)
+	assert.Equal(t, `7`, string(responses[7].Id))
+	assert.Equal(t, 2, maxRunning)
+}
@@ -0,0 +1,47 @@
+package limiter
+
+import (
+	"math"
+	"time"
+)
+
+// Algorithm adjusts a concurrency limit from the samples of completed requests.
+type Algorithm interface {
+	Update(limit int, rtt time.Duration, dropped bool) int
+}
+
+// AIMD increases the limit by one while requests complete below the latency
+// threshold and multiplies it by BackoffRatio when they are slower or dropped.
+type AIMD struct {
+	MinLimit         int
+	MaxLimit         int
+	LatencyThreshold time.Duration
+	BackoffRatio     float64
+}
+
+func NewAIMD(minLimit int, maxLimit int, latencyThreshold time.Duration) *AIMD {
+	return &AIMD{
+		MinLimit:         minLimit,
+		MaxLimit:         maxLimit,
+		LatencyThreshold: latencyThreshold,
+		BackoffRatio:     0.9,
+	}
+}
+
+func (a *AIMD) Update(limit int, rtt time.Duration, dropped bool) int {
+	if dropped || rtt > a.LatencyThreshold {
+		limit = int(math.Floor(float64(limit) * a.BackoffRatio))
+	} else {
+		limit++
+	}
+
+	if limit < a.MinLimit {
+		return a.MinLimit
+	}
+	if limit > a.MaxLimit {
+		return a.MaxLimit
+	}
+	return limit
+}
+
+var _ Algorithm = (*AIMD)(nil)
@@ -0,0 +1,150 @@
+package limiter
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull   = errors.New("concurrency limit reached and queue is full")
+	ErrWaitTimeout = errors.New("concurrency limit reached and queue wait expired")
+)
+
+type Options struct {
+	Limit     int
+	MaxQueue  int
+	MaxWait   time.Duration
+	Algorithm Algorithm
+}
+
+func NewOptions(limit int) *Options {
+	return &Options{
+		Limit:    limit,
+		MaxQueue: limit,
+		MaxWait:  100 * time.Millisecond,
+	}
+}
+
+func (o *Options) WithQueue(maxQueue int, maxWait time.Duration) *Options {
+	o.MaxQueue = maxQueue
+	o.MaxWait = maxWait
+	return o
+}
+
+func (o *Options) WithAlgorithm(algorithm Algorithm) *Options {
+	o.Algorithm = algorithm
+	return o
+}
+
+// ReleaseFunc returns the slot to the limiter. Dropped requests are reported to
+// the algorithm as overload signals.
+type ReleaseFunc func(dropped bool)
+
+type Limiter struct {
+	name     string
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	queue    *list.List
+	options  *Options
+	metrics  *metrics
+}
+
+func New(name string, options *Options) *Limiter {
+	return &Limiter{
+		name:    name,
+		limit:   options.Limit,
+		queue:   list.New(),
+		options: options,
+		metrics: newMetrics(name),
+	}
+}
+
+func (l *Limiter) Name() string {
+	return l.name
+}
+
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+func (l *Limiter) Acquire(ctx context.Context) (ReleaseFunc, error) {
+	l.mu.Lock()
+	if l.inFlight < l.limit && l.queue.Len() == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return l.acquired(ctx), nil
+	}
+
+	if l.queue.Len() >= l.options.MaxQueue {
+		l.mu.Unlock()
+		l.metrics.rejected(ctx, ErrQueueFull)
+		return nil, ErrQueueFull
+	}
+
+	granted := make(chan struct{})
+	element := l.queue.PushBack(granted)
+	l.mu.Unlock()
+
+	l.metrics.queued.Add(ctx, 1, l.metrics.attributes...)
+	defer l.metrics.queued.Add(ctx, -1, l.metrics.attributes...)
+
+	timer := time.NewTimer(l.options.MaxWait)
+	defer timer.Stop()
+
+	select {
+	case <-granted:
+		return l.acquired(ctx), nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	select {
+	case <-granted:
+		// The slot was handed over while the wait expired.
+		l.mu.Unlock()
+		return l.acquired(ctx), nil
+	default:
+		l.queue.Remove(element)
+		l.mu.Unlock()
+	}
+
+	l.metrics.rejected(ctx, ErrWaitTimeout)
+	return nil, ErrWaitTimeout
+}
+
+func (l *Limiter) acquired(ctx context.Context) ReleaseFunc {
+	started := time.Now()
+	l.metrics.inFlight.Add(ctx, 1, l.metrics.attributes...)
+
+	var once sync.Once
+	return func(dropped bool) {
+		once.Do(func() {
+			l.release(time.Since(started), dropped)
+			l.metrics.inFlight.Add(ctx, -1, l.metrics.attributes...)
+		})
+	}
+}
+
+func (l *Limiter) release(rtt time.Duration, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if l.options.Algorithm != nil {
+		l.limit = l.options.Algorithm.Update(l.limit, rtt, dropped)
+	}
+
+	for l.inFlight < l.limit && l.queue.Len() > 0 {
+		granted := l.queue.Remove(l.queue.Front()).(chan struct{})
+		l.inFlight++
+		close(granted)
+	}
+}
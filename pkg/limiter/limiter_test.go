@@ -0,0 +1,66 @@
+package limiter
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Acquire(t *testing.T) {
+	l := New("test", NewOptions(1).WithQueue(1, 50*time.Millisecond))
+
+	release, err := l.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	queued := make(chan error, 1)
+	go func() {
+		r, err := l.Acquire(context.Background())
+		if err == nil {
+			r(false)
+		}
+		queued <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.queue.Len() == 1
+	}, time.Second, time.Millisecond)
+
+	_, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	release(false)
+	assert.NoError(t, <-queued)
+
+	release, err = l.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	_, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrWaitTimeout)
+	release(false)
+}
+
+func TestAIMD_Update(t *testing.T) {
+	aimd := NewAIMD(2, 20, 100*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		limit    int
+		rtt      time.Duration
+		dropped  bool
+		expected int
+	}{
+		{name: "Should increase limit given fast request", limit: 10, rtt: 10 * time.Millisecond, expected: 11},
+		{name: "Should decrease limit given slow request", limit: 10, rtt: time.Second, expected: 9},
+		{name: "Should decrease limit given dropped request", limit: 10, rtt: 10 * time.Millisecond, dropped: true, expected: 9},
+		{name: "Should keep minimum limit given slow request", limit: 2, rtt: time.Second, expected: 2},
+		{name: "Should keep maximum limit given fast request", limit: 20, rtt: 10 * time.Millisecond, expected: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, aimd.Update(tt.limit, tt.rtt, tt.dropped))
+		})
+	}
+}
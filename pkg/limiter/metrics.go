@@ -0,0 +1,37 @@
+package limiter
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+const instrumentationName = "github.com/yurikilian/bills/pkg/limiter"
+
+type metrics struct {
+	attributes []attribute.KeyValue
+	inFlight   instrument.Int64UpDownCounter
+	queued     instrument.Int64UpDownCounter
+	rejections instrument.Int64Counter
+}
+
+func newMetrics(name string) *metrics {
+	meter := global.Meter(instrumentationName)
+
+	// Instrument creation only fails on invalid names, which are constant here.
+	inFlight, _ := meter.Int64UpDownCounter("limiter.in_flight", instrument.WithDescription("Requests holding a concurrency slot"))
+	queued, _ := meter.Int64UpDownCounter("limiter.queued", instrument.WithDescription("Requests waiting for a concurrency slot"))
+	rejections, _ := meter.Int64Counter("limiter.rejected", instrument.WithDescription("Requests shed by the concurrency limiter"))
+
+	return &metrics{
+		attributes: []attribute.KeyValue{attribute.String("limiter", name)},
+		inFlight:   inFlight,
+		queued:     queued,
+		rejections: rejections,
+	}
+}
+
+func (m *metrics) rejected(ctx context.Context, reason error) {
+	m.rejections.Add(ctx, 1, append(m.attributes, attribute.String("reason", reason.Error()))...)
+}
@@ -1,6 +1,10 @@
 package logger
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 type Level int
 
@@ -14,6 +18,12 @@ const (
 	FatalLevel
 )
 
+// LevelSwitcher is implemented by loggers whose level can change at runtime.
+type LevelSwitcher interface {
+	Level() Level
+	SetLevel(level Level)
+}
+
 type Logger interface {
 	Trace(context.Context, string)
 	Debug(context.Context, string)
@@ -33,6 +43,16 @@ var LevelLabelMap = map[Level]string{
 	FatalLevel: "FATAL",
 }
 
+// ParseLevel returns the level of a label such as "INFO", ignoring case.
+func ParseLevel(label string) (Level, error) {
+	for level, levelLabel := range LevelLabelMap {
+		if strings.EqualFold(levelLabel, label) {
+			return level, nil
+		}
+	}
+	return NoOp, fmt.Errorf("unknown log level %q", label)
+}
+
 type Fields map[string]string
 
 type (
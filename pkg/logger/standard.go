@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,6 +20,7 @@ type StandardLogOptions struct {
 type StandardLog struct {
 	stdLogger *log.Logger
 	options   *StandardLogOptions
+	level     atomic.Int32
 	exitCall  func()
 }
 
@@ -36,6 +38,7 @@ func NewStandardLog(options *StandardLogOptions) *StandardLog {
 		options:   options,
 	}
 
+	standardLog.level.Store(int32(options.Level))
 	standardLog.SetExitCall(func() {
 		os.Exit(1)
 	})
@@ -75,8 +78,17 @@ func (l *StandardLog) SetExitCall(exitCall func()) {
 	l.exitCall = exitCall
 }
 
+func (l *StandardLog) Level() Level {
+	return Level(l.level.Load())
+}
+
+func (l *StandardLog) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
 func (l *StandardLog) log(ctx context.Context, level Level, message string) {
-	if l.options.Level == NoOp {
+	minLevel := l.Level()
+	if minLevel == NoOp {
 		return
 	}
 
@@ -87,7 +99,7 @@ func (l *StandardLog) log(ctx context.Context, level Level, message string) {
 			"severityNumber": strconv.Itoa(int(level)),
 		}, message)
 
-	if isLevelWritableAndInRange(level) && level >= l.options.Level {
+	if isLevelWritableAndInRange(level) && level >= minLevel {
 
 		if level >= FatalLevel {
 			l.stdLogger.Output(2, line)
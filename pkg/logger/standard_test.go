@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"io"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -312,6 +313,23 @@ func Test_LogLevel(t *testing.T) {
 	}
 }
 
+func Test_SetLevel(t *testing.T) {
+	output := new(strings.Builder)
+	l := newLogToTest(output, ErrorLevel)
+
+	l.Info(context.TODO(), "Should not write")
+	level, err := ParseLevel("info")
+	assert.NoError(t, err)
+	l.SetLevel(level)
+	l.Info(context.TODO(), "This is an info message")
+
+	assert.Equal(t, InfoLevel, l.Level())
+	assert.Equal(t, "This is an info message\n", output.String())
+
+	_, err = ParseLevel("verbose")
+	assert.EqualError(t, err, `unknown log level "verbose"`)
+}
+
 func newLogToTest(w io.Writer, level Level) *StandardLog {
 	log := NewStandardLog(&StandardLogOptions{
 		Output:    w,
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/apikey"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"strings"
+)
+
+type ApiKeyOptions struct {
+	Store      *apikey.Store
+	Header     string
+	QueryParam string
+}
+
+func NewApiKeyOptions(store *apikey.Store) *ApiKeyOptions {
+	return &ApiKeyOptions{
+		Store:      store,
+		Header:     "X-API-Key",
+		QueryParam: "api_key",
+	}
+}
+
+func ApiKey(options *ApiKeyOptions) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			rawKey := readApiKey(ctx, options)
+			if len(rawKey) == 0 {
+				return exception.NewUnauthorizedProblem("An API key is required")
+			}
+
+			key, err := options.Store.Verify(ctx.ReqCtx(), rawKey)
+			if err != nil {
+				return apiKeyProblem(err)
+			}
+
+			if err := options.Store.Touch(ctx.ReqCtx(), key); err != nil {
+				ctx.Logger().Warn(ctx.ReqCtx(), fmt.Sprintf("could not record api key %v usage: %v", key.Prefix, err))
+			}
+
+			ctx.SetRequest(ctx.Request().WithContext(apikey.WithKey(ctx.ReqCtx(), key)))
+			return next(ctx)
+		}
+	}
+}
+
+func RequireScopes(scopes ...string) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			key, ok := apikey.FromContext(ctx.ReqCtx())
+			if !ok {
+				return exception.NewUnauthorizedProblem("An API key is required")
+			}
+
+			for _, scope := range scopes {
+				if !key.HasScope(scope) {
+					return exception.NewForbiddenProblem(fmt.Sprintf("The API key is missing the %v scope", scope))
+				}
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+func readApiKey(ctx server.IHttpContext, options *ApiKeyOptions) string {
+	if len(options.Header) > 0 {
+		if value := strings.TrimSpace(ctx.Request().Header.Get(options.Header)); len(value) > 0 {
+			return value
+		}
+	}
+
+	if len(options.QueryParam) > 0 {
+		return ctx.Request().URL.Query().Get(options.QueryParam)
+	}
+
+	return ""
+}
+
+func apiKeyProblem(err error) error {
+	switch {
+	case errors.Is(err, apikey.ErrExpiredKey):
+		return exception.NewUnauthorizedProblem("The API key is expired")
+	case errors.Is(err, apikey.ErrRevokedKey):
+		return exception.NewUnauthorizedProblem("The API key was revoked")
+	case errors.Is(err, apikey.ErrMalformedKey), errors.Is(err, apikey.ErrInvalidKey):
+		return exception.NewUnauthorizedProblem("The API key is invalid")
+	default:
+		return exception.NewInternalServerError(err.Error())
+	}
+}
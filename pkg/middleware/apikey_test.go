@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/apikey"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"github.com/yurikilian/bills/pkg/server/servertest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestApiKey(t *testing.T) {
+	ctx := context.Background()
+	store := apikey.NewInMemoryStore()
+
+	valid, _, err := store.Issue(ctx, "valid", []string{"transactions:read"}, time.Hour)
+	assert.NoError(t, err)
+	// A key expiring within the current second is already expired.
+	expired, _, err := store.Issue(ctx, "expired", nil, time.Nanosecond)
+	assert.NoError(t, err)
+	revoked, revokedKey, err := store.Issue(ctx, "revoked", nil, time.Hour)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Revoke(ctx, revokedKey.Prefix))
+
+	handler := func(ctx server.IHttpContext) error {
+		key, _ := apikey.FromContext(ctx.ReqCtx())
+		return ctx.WriteResponse(http.StatusOK, key.Name)
+	}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/transactions", handler).
+			Get("/reports", handler)).
+		Use(ApiKey(NewApiKeyOptions(store)))
+
+	tests := []struct {
+		name               string
+		path               string
+		header             string
+		expectedStatusCode int
+		expectedBody       string
+		expectedEx         exception.Problem
+	}{
+		{
+			name:               "Should return 401 given missing api key",
+			path:               "/transactions",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedEx:         exception.NewUnauthorizedProblem("An API key is required"),
+		},
+		{
+			name:               "Should return 401 given malformed api key",
+			path:               "/transactions",
+			header:             "not-a-key",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedEx:         exception.NewUnauthorizedProblem("The API key is invalid"),
+		},
+		{
+			name:               "Should return 401 given unknown api key",
+			path:               "/transactions",
+			header:             "bk_000000000000.c2VjcmV0",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedEx:         exception.NewUnauthorizedProblem("The API key is invalid"),
+		},
+		{
+			name:               "Should return 401 given expired api key",
+			path:               "/transactions",
+			header:             expired,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedEx:         exception.NewUnauthorizedProblem("The API key is expired"),
+		},
+		{
+			name:               "Should return 401 given revoked api key",
+			path:               "/transactions",
+			header:             revoked,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedEx:         exception.NewUnauthorizedProblem("The API key was revoked"),
+		},
+		{
+			name:               "Should call handler given valid api key",
+			path:               "/transactions",
+			header:             valid,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "\"valid\"\n",
+		},
+		{
+			name:               "Should call handler given valid api key in query param",
+			path:               "/reports?api_key=" + valid,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "\"valid\"\n",
+		},
+	}
+	client := servertest.New(t, restServer)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := client.Get(tt.path)
+			if len(tt.header) > 0 {
+				req.WithHeader("X-API-Key", tt.header)
+			}
+			res := req.Do().AssertStatus(tt.expectedStatusCode)
+
+			if tt.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, string(res.Body))
+			} else {
+				res.AssertProblem(tt.expectedEx)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/cache"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type CacheOptions struct {
+	Cache cache.Cache
+	// DefaultTTL applies to responses without max-age. Zero only caches
+	// responses that declare their freshness.
+	DefaultTTL           time.Duration
+	StaleWhileRevalidate time.Duration
+	// VaryHeaders are part of the cache key. Responses varying on other
+	// request headers, e.g. Accept-Encoding set by an inner Compress, are not
+	// stored unless listed here.
+	VaryHeaders []string
+	// TagHeader is read from responses to tag cached entries and, on
+	// successful writes, to invalidate them. It is never sent to clients.
+	TagHeader string
+	// CredentialHeaders and CredentialQueryParams mark requests made on behalf
+	// of a caller. Their responses are never stored nor served from the cache.
+	CredentialHeaders     []string
+	CredentialQueryParams []string
+	invalidations         map[string][]string
+}
+
+func NewCacheOptions(c cache.Cache) *CacheOptions {
+	return &CacheOptions{
+		Cache:                 c,
+		TagHeader:             "Cache-Tag",
+		CredentialHeaders:     []string{"Authorization", "X-API-Key"},
+		CredentialQueryParams: []string{"api_key"},
+		invalidations:         map[string][]string{},
+	}
+}
+
+// WithInvalidation invalidates tags whenever the route answers with a 2xx.
+func (o *CacheOptions) WithInvalidation(method string, pattern string, tags ...string) *CacheOptions {
+	o.invalidations[routeKey(method, pattern)] = append(o.invalidations[routeKey(method, pattern)], tags...)
+	return o
+}
+
+func ResponseCache(options *CacheOptions) server.Middleware {
+	var revalidating sync.Map
+
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			if ctx.Request().Method != http.MethodGet {
+				return options.invalidateOnSuccess(ctx, next)
+			}
+
+			requestDirectives := parseCacheControl(ctx.Request().Header.Get("Cache-Control"))
+			if _, noStore := requestDirectives["no-store"]; noStore || options.hasCredentials(ctx.Request()) {
+				return options.bypass(ctx, next)
+			}
+
+			key := options.key(ctx.Request())
+			now := time.Now()
+
+			if entry, ok := options.Cache.Get(ctx.ReqCtx(), key); ok && acceptsCached(requestDirectives) {
+				if entry.IsFresh(now) {
+					return options.replay(ctx, entry, "HIT", now)
+				}
+
+				if entry.IsUsableStale(now) {
+					if _, running := revalidating.LoadOrStore(key, true); !running {
+						go func(revalidationCtx server.IHttpContext) {
+							defer revalidating.Delete(key)
+							defer func() {
+								if p := recover(); p != nil {
+									revalidationCtx.Logger().Error(revalidationCtx.ReqCtx(), fmt.Sprintf("panic while revalidating cached %v: %v", revalidationCtx.Request().URL.Path, p))
+								}
+							}()
+							options.revalidate(revalidationCtx, next, key)
+						}(options.revalidationContext(ctx))
+					}
+					return options.replay(ctx, entry, "STALE", now)
+				}
+			}
+
+			writer := ctx.Writer()
+			recorder := newResponseRecorder(&tagStrippingWriter{ResponseWriter: writer, tagHeader: options.TagHeader})
+			ctx.SetWriter(recorder)
+			options.setVary(writer.Header())
+			writer.Header().Set("X-Cache", "MISS")
+			// Headers set so far, e.g. a per request nonce of outer
+			// middlewares, must not be replayed to other clients.
+			outer := writer.Header().Clone()
+
+			err := next(ctx)
+			ctx.SetWriter(writer)
+
+			if err == nil {
+				options.store(ctx.ReqCtx(), key, recorder.response(), outer, recorder.tags(options.TagHeader), time.Now())
+			}
+			return err
+		}
+	}
+}
+
+func (o *CacheOptions) key(request *http.Request) string {
+	var sb strings.Builder
+	sb.WriteString(request.Method)
+	sb.WriteString(" ")
+	sb.WriteString(request.URL.RequestURI())
+	for _, name := range o.VaryHeaders {
+		sb.WriteString("\n")
+		sb.WriteString(name)
+		sb.WriteString(":")
+		sb.WriteString(request.Header.Get(name))
+	}
+	return sb.String()
+}
+
+// bypass answers from the handler without touching the cache.
+func (o *CacheOptions) bypass(ctx server.IHttpContext, next server.HttpMethodHandler) error {
+	writer := ctx.Writer()
+	ctx.SetWriter(&tagStrippingWriter{ResponseWriter: writer, tagHeader: o.TagHeader})
+	defer ctx.SetWriter(writer)
+
+	return next(ctx)
+}
+
+func (o *CacheOptions) hasCredentials(request *http.Request) bool {
+	for _, name := range o.CredentialHeaders {
+		if len(request.Header.Get(name)) > 0 {
+			return true
+		}
+	}
+
+	query := request.URL.Query()
+	for _, name := range o.CredentialQueryParams {
+		if query.Has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversVary reports whether the cache key includes every request header the
+// response varies on.
+func (o *CacheOptions) coversVary(header http.Header) bool {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if len(name) == 0 {
+				continue
+			}
+			if !o.varies(name) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (o *CacheOptions) varies(name string) bool {
+	for _, vary := range o.VaryHeaders {
+		if strings.EqualFold(vary, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *CacheOptions) setVary(header http.Header) {
+	for _, name := range o.VaryHeaders {
+		header.Add("Vary", name)
+	}
+}
+
+func (o *CacheOptions) replay(ctx server.IHttpContext, entry *cache.Entry, status string, now time.Time) error {
+	header := ctx.Writer().Header()
+	for name, values := range entry.Header {
+		header[name] = values
+	}
+	header.Set("Age", strconv.Itoa(int(now.Sub(entry.StoredAt).Seconds())))
+	header.Set("X-Cache", status)
+
+	if server.NotModified(ctx.Request(), header) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		ctx.Writer().WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	ctx.Writer().WriteHeader(entry.StatusCode)
+	_, err := ctx.Writer().Write(entry.Body)
+	return err
+}
+
+// revalidationContext detaches the request from the client so the background
+// refresh survives the end of the current response.
+func (o *CacheOptions) revalidationContext(ctx server.IHttpContext) server.IHttpContext {
+	revalidationCtx := ctx.Clone()
+	request := ctx.Request().Clone(context.Background())
+	request.Header.Del("Cache-Control")
+	revalidationCtx.SetRequest(request)
+	return revalidationCtx
+}
+
+func (o *CacheOptions) revalidate(ctx server.IHttpContext, next server.HttpMethodHandler, key string) {
+	writer := &discardResponseWriter{header: http.Header{}}
+	recorder := newResponseRecorder(writer)
+	ctx.SetWriter(recorder)
+
+	if err := next(ctx); err != nil {
+		ctx.Logger().Warn(ctx.ReqCtx(), fmt.Sprintf("could not revalidate cached %v: %v", ctx.Request().URL.Path, err))
+		return
+	}
+	o.store(ctx.ReqCtx(), key, recorder.response(), http.Header{}, recorder.tags(o.TagHeader), time.Now())
+}
+
+// store keeps the response when it is cacheable. Only the headers which
+// differ from outer, the ones set before the handler ran, are stored.
+func (o *CacheOptions) store(ctx context.Context, key string, response *recordedResponse, outer http.Header, tags []string, now time.Time) {
+	if response.StatusCode != http.StatusOK || !o.coversVary(response.Header) {
+		return
+	}
+
+	directives := parseCacheControl(response.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return
+	}
+	if _, ok := directives["private"]; ok {
+		return
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return
+	}
+
+	ttl := o.DefaultTTL
+	if maxAge, ok := directiveSeconds(directives, "s-maxage"); ok {
+		ttl = maxAge
+	} else if maxAge, ok := directiveSeconds(directives, "max-age"); ok {
+		ttl = maxAge
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	stale := o.StaleWhileRevalidate
+	if swr, ok := directiveSeconds(directives, "stale-while-revalidate"); ok {
+		stale = swr
+	}
+
+	header := http.Header{}
+	for name, values := range response.Header {
+		if name == "Vary" || !equalValues(outer[name], values) {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+	header.Del(o.TagHeader)
+	header.Del("X-Cache")
+
+	o.Cache.Set(ctx, key, &cache.Entry{
+		StatusCode: response.StatusCode,
+		Header:     header,
+		Body:       append([]byte(nil), response.Body...),
+		Tags:       tags,
+		StoredAt:   now,
+		FreshUntil: now.Add(ttl),
+		StaleUntil: now.Add(ttl + stale),
+	})
+}
+
+func (o *CacheOptions) invalidateOnSuccess(ctx server.IHttpContext, next server.HttpMethodHandler) error {
+	writer := ctx.Writer()
+	recorder := newResponseRecorder(&tagStrippingWriter{ResponseWriter: writer, tagHeader: o.TagHeader})
+	ctx.SetWriter(recorder)
+
+	err := next(ctx)
+	ctx.SetWriter(writer)
+
+	response := recorder.response()
+	if err != nil || response.StatusCode < 200 || response.StatusCode >= 300 {
+		return err
+	}
+
+	tags := append(recorder.tags(o.TagHeader), o.invalidations[routeKey(ctx.Request().Method, ctx.Route())]...)
+	if len(tags) > 0 {
+		o.Cache.InvalidateTags(ctx.ReqCtx(), tags...)
+	}
+	return nil
+}
+
+func acceptsCached(directives map[string]string) bool {
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+	if maxAge, ok := directiveSeconds(directives, "max-age"); ok && maxAge == 0 {
+		return false
+	}
+	return true
+}
+
+func parseCacheControl(value string) map[string]string {
+	directives := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		name, argument, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if len(name) > 0 {
+			directives[strings.ToLower(name)] = strings.Trim(argument, `"`)
+		}
+	}
+	return directives
+}
+
+func directiveSeconds(directives map[string]string, name string) (time.Duration, bool) {
+	value, ok := directives[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// tagStrippingWriter removes the internal tag header right before the
+// response leaves the server.
+type tagStrippingWriter struct {
+	http.ResponseWriter
+	tagHeader string
+}
+
+func (w *tagStrippingWriter) WriteHeader(statusCode int) {
+	w.ResponseWriter.Header().Del(w.tagHeader)
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/cache"
+	"github.com/yurikilian/bills/pkg/csp"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func etag(body string) string {
+	return server.WeakETag([]byte(body))
+}
+
+func TestResponseCache(t *testing.T) {
+	computed := 0
+	summary := func(ctx server.IHttpContext) error {
+		computed++
+		ctx.Writer().Header().Set("Cache-Control", "max-age=60")
+		ctx.Writer().Header().Set("Cache-Tag", "transactions")
+		return ctx.WriteResponse(http.StatusOK, computed)
+	}
+	create := func(ctx server.IHttpContext) error {
+		ctx.Writer().WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	options := NewCacheOptions(cache.NewLRU(1<<20)).
+		WithInvalidation(http.MethodPost, "/transactions", "transactions")
+	options.VaryHeaders = []string{"Accept-Language"}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/summary", summary).
+			POST("/transactions", create)).
+		Use(ResponseCache(options))
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedBody       string
+		expectedCache      string
+	}{
+		{name: "Should compute response given empty cache", method: http.MethodGet, path: "/summary", expectedBody: "1\n", expectedCache: "MISS"},
+		{name: "Should replay response given fresh entry", method: http.MethodGet, path: "/summary", expectedBody: "1\n", expectedCache: "HIT"},
+		{name: "Should compute response given different vary header", method: http.MethodGet, path: "/summary", headers: map[string]string{"Accept-Language": "pt"}, expectedBody: "2\n", expectedCache: "MISS"},
+		{name: "Should compute response given client no-cache", method: http.MethodGet, path: "/summary", headers: map[string]string{"Cache-Control": "no-cache"}, expectedBody: "3\n", expectedCache: "MISS"},
+		{name: "Should replay refreshed response after client no-cache", method: http.MethodGet, path: "/summary", expectedBody: "3\n", expectedCache: "HIT"},
+		{name: "Should return not modified given fresh entry matching If-None-Match", method: http.MethodGet, path: "/summary", headers: map[string]string{"If-None-Match": etag("3\n")}, expectedStatusCode: http.StatusNotModified, expectedCache: "HIT"},
+		{name: "Should bypass cache given Authorization header", method: http.MethodGet, path: "/summary", headers: map[string]string{"Authorization": "Bearer token"}, expectedBody: "4\n"},
+		{name: "Should bypass cache given API key header", method: http.MethodGet, path: "/summary", headers: map[string]string{"X-API-Key": "bk_key"}, expectedBody: "5\n"},
+		{name: "Should bypass cache given API key query parameter", method: http.MethodGet, path: "/summary?api_key=bk_key", expectedBody: "6\n"},
+		{name: "Should keep anonymous entry given authenticated requests", method: http.MethodGet, path: "/summary", expectedBody: "3\n", expectedCache: "HIT"},
+		{name: "Should invalidate tags given successful write", method: http.MethodPost, path: "/transactions"},
+		{name: "Should compute response given invalidated entry", method: http.MethodGet, path: "/summary", expectedBody: "7\n", expectedCache: "MISS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, req)
+
+			assert.Empty(t, rec.Header().Get("Cache-Tag"))
+			if tt.expectedStatusCode == http.StatusNotModified {
+				assert.Equal(t, http.StatusNotModified, rec.Code)
+				assert.Empty(t, rec.Body.String())
+				assert.Equal(t, tt.expectedCache, rec.Header().Get("X-Cache"))
+			}
+			if len(tt.expectedBody) > 0 {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+				assert.Equal(t, tt.expectedCache, rec.Header().Get("X-Cache"))
+			}
+		})
+	}
+}
+
+func TestResponseCache_Headers(t *testing.T) {
+	large := strings.Repeat("transaction ", 200)
+	handler := func(ctx server.IHttpContext) error {
+		ctx.Writer().Header().Set("Cache-Control", "max-age=60")
+		return ctx.WriteResponse(http.StatusOK, large)
+	}
+
+	security := NewSecurityHeadersOptions()
+	security.ContentSecurityPolicy = csp.NewPolicy().DefaultSrc(csp.Self).ScriptSrc(csp.Self, csp.Nonce)
+
+	newServer := func(options *CacheOptions) *server.RestServer {
+		return server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+			Router(server.NewRestRouter().Get("/summary", handler)).
+			Use(SecurityHeaders(security)).
+			Use(ResponseCache(options)).
+			Use(Compress(NewCompressOptions()))
+	}
+	get := func(restServer *server.RestServer, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/summary", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		restServer.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Should not store response given vary header missing from the key", func(t *testing.T) {
+		restServer := newServer(NewCacheOptions(cache.NewLRU(1 << 20)))
+
+		compressed := get(restServer, "gzip")
+		assert.Equal(t, "MISS", compressed.Header().Get("X-Cache"))
+		assert.Equal(t, "gzip", compressed.Header().Get("Content-Encoding"))
+
+		plain := get(restServer, "")
+		assert.Equal(t, "MISS", plain.Header().Get("X-Cache"))
+		assert.Empty(t, plain.Header().Get("Content-Encoding"))
+		assert.Contains(t, plain.Body.String(), "transaction")
+	})
+
+	t.Run("Should replay response per vary header given it is part of the key", func(t *testing.T) {
+		options := NewCacheOptions(cache.NewLRU(1 << 20))
+		options.VaryHeaders = []string{"Accept-Encoding"}
+		restServer := newServer(options)
+
+		first := get(restServer, "gzip")
+		replayed := get(restServer, "gzip")
+		assert.Equal(t, "HIT", replayed.Header().Get("X-Cache"))
+		assert.Equal(t, "gzip", replayed.Header().Get("Content-Encoding"))
+		assert.NotEqual(t, first.Header().Get("Content-Security-Policy"), replayed.Header().Get("Content-Security-Policy"),
+			"the nonce of the first response must not be replayed")
+
+		plain := get(restServer, "")
+		assert.Equal(t, "MISS", plain.Header().Get("X-Cache"))
+		assert.Empty(t, plain.Header().Get("Content-Encoding"))
+	})
+}
+
+func TestResponseCache_RevalidationPanic(t *testing.T) {
+	revalidated := make(chan struct{})
+	handler := func(ctx server.IHttpContext) error {
+		close(revalidated)
+		panic("boom")
+	}
+
+	options := NewCacheOptions(cache.NewLRU(1 << 20))
+	now := time.Now()
+	options.Cache.Set(context.Background(), "GET /summary", &cache.Entry{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       []byte("1\n"),
+		StoredAt:   now.Add(-2 * time.Minute),
+		FreshUntil: now.Add(-time.Minute),
+		StaleUntil: now.Add(time.Minute),
+	})
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().Get("/summary", handler)).
+		Use(ResponseCache(options))
+
+	rec := httptest.NewRecorder()
+	restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/summary", nil))
+	assert.Equal(t, "STALE", rec.Header().Get("X-Cache"))
+	assert.Equal(t, "1\n", rec.Body.String())
+
+	select {
+	case <-revalidated:
+	case <-time.After(time.Second):
+		t.Fatal("entry was not revalidated")
+	}
+}
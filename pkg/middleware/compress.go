@@ -0,0 +1,352 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+type CompressOptions struct {
+	Level int
+	// MinSize is the smallest body, in bytes, worth compressing.
+	MinSize              int
+	MediaTypes           []string
+	MaxDecompressedBytes int64
+}
+
+func NewCompressOptions() *CompressOptions {
+	return &CompressOptions{
+		Level:   gzip.DefaultCompression,
+		MinSize: 1024,
+		MediaTypes: []string{
+			"application/json",
+			"application/problem+json",
+			"application/javascript",
+			"application/xml",
+			"image/svg+xml",
+			"text/*",
+		},
+		MaxDecompressedBytes: 10 << 20,
+	}
+}
+
+func (o *CompressOptions) isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json") {
+		return true
+	}
+
+	for _, allowed := range o.MediaTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+type compressorPool struct {
+	gzip    sync.Pool
+	deflate sync.Pool
+}
+
+func newCompressorPool(level int) *compressorPool {
+	pool := &compressorPool{}
+	pool.gzip.New = func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, level)
+		return w
+	}
+	pool.deflate.New = func() interface{} {
+		w, _ := zlib.NewWriterLevel(io.Discard, level)
+		return w
+	}
+	return pool
+}
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func (p *compressorPool) get(encoding string, w io.Writer) compressor {
+	var c compressor
+	if encoding == encodingGzip {
+		c = p.gzip.Get().(*gzip.Writer)
+	} else {
+		c = p.deflate.Get().(*zlib.Writer)
+	}
+	c.Reset(w)
+	return c
+}
+
+func (p *compressorPool) put(encoding string, c compressor) {
+	if encoding == encodingGzip {
+		p.gzip.Put(c)
+	} else {
+		p.deflate.Put(c)
+	}
+}
+
+func Compress(options *CompressOptions) server.Middleware {
+	pool := newCompressorPool(options.Level)
+
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			if err := decompressRequest(ctx, options); err != nil {
+				return err
+			}
+
+			ctx.Writer().Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(ctx.Request().Header.Get("Accept-Encoding"))
+			if len(encoding) == 0 {
+				return next(ctx)
+			}
+
+			writer := ctx.Writer()
+			compressWriter := &compressResponseWriter{
+				ResponseWriter: writer,
+				options:        options,
+				pool:           pool,
+				encoding:       encoding,
+				head:           ctx.Request().Method == http.MethodHead,
+			}
+			ctx.SetWriter(compressWriter)
+			defer ctx.SetWriter(writer)
+
+			err := next(ctx)
+			if cErr := compressWriter.close(); cErr != nil && err == nil {
+				return exception.NewInternalServerError(cErr.Error())
+			}
+			return err
+		}
+	}
+}
+
+func decompressRequest(ctx server.IHttpContext, options *CompressOptions) error {
+	request := ctx.Request()
+	encoding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	switch encoding {
+	case "", "identity":
+		return nil
+	case encodingGzip:
+		reader, err = gzip.NewReader(request.Body)
+	case encodingDeflate:
+		reader, err = zlib.NewReader(request.Body)
+	default:
+		return exception.NewUnsupportedMediaType(fmt.Sprintf("Content-Encoding %v is not supported", encoding))
+	}
+	if err != nil {
+		return exception.NewMalformedRequestProblem()
+	}
+
+	request.Body = http.MaxBytesReader(ctx.Writer(), reader, options.MaxDecompressedBytes)
+	request.Header.Del("Content-Encoding")
+	request.Header.Del("Content-Length")
+	request.ContentLength = -1
+	return nil
+}
+
+// negotiateEncoding picks the supported encoding with the highest quality,
+// preferring gzip on ties. It returns an empty string for identity.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// compressResponseWriter buffers the first MinSize bytes to decide whether the
+// response is worth compressing, then streams through a pooled compressor.
+// HEAD responses carry no body, so they are negotiated from the announced
+// Content-Length instead.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	options     *CompressOptions
+	pool        *compressorPool
+	encoding    string
+	head        bool
+	buffer      bytes.Buffer
+	statusCode  int
+	decided     bool
+	compressor  compressor
+	wroteHeader bool
+}
+
+func (w *compressResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *compressResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	if w.decided {
+		return w.write(data)
+	}
+
+	w.buffer.Write(data)
+	if w.buffer.Len() >= w.options.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("the response writer does not support hijacking")
+}
+
+func (w *compressResponseWriter) decide(worthIt bool) error {
+	w.decided = true
+	header := w.Header()
+
+	if len(header.Get("Content-Type")) == 0 && w.buffer.Len() > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buffer.Bytes()))
+	}
+
+	compress := worthIt &&
+		len(header.Get("Content-Encoding")) == 0 &&
+		w.statusCode != http.StatusNoContent &&
+		w.statusCode != http.StatusNotModified &&
+		w.options.isCompressible(header.Get("Content-Type"))
+
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if !w.head {
+			w.compressor = w.pool.get(w.encoding, w.ResponseWriter)
+		}
+	}
+
+	w.writeHeader()
+	if w.buffer.Len() == 0 || w.head {
+		w.buffer.Reset()
+		return nil
+	}
+
+	_, err := w.write(w.buffer.Bytes())
+	w.buffer.Reset()
+	return err
+}
+
+func (w *compressResponseWriter) writeHeader() {
+	if !w.wroteHeader && w.statusCode != 0 {
+		w.wroteHeader = true
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+}
+
+func (w *compressResponseWriter) write(data []byte) (int, error) {
+	if w.head {
+		return len(data), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressResponseWriter) announcedLength() int {
+	length, err := strconv.Atoi(w.Header().Get("Content-Length"))
+	if err != nil {
+		return 0
+	}
+	return length
+}
+
+func (w *compressResponseWriter) close() error {
+	if !w.decided {
+		if w.statusCode == 0 {
+			// Nothing was written, leave the response untouched for the error handler.
+			return nil
+		}
+		if err := w.decide(w.head && w.announcedLength() >= w.options.MinSize); err != nil {
+			return err
+		}
+	}
+
+	if w.compressor == nil {
+		return nil
+	}
+
+	err := w.compressor.Close()
+	w.pool.put(w.encoding, w.compressor)
+	w.compressor = nil
+	return err
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("transaction ", 200)
+
+	var uploaded string
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/large", func(ctx server.IHttpContext) error { return ctx.WriteResponse(http.StatusOK, large) }).
+			Get("/small", func(ctx server.IHttpContext) error { return ctx.WriteResponse(http.StatusOK, "small") }).
+			POST("/upload", func(ctx server.IHttpContext) error {
+				body, err := io.ReadAll(ctx.Request().Body)
+				uploaded = string(body)
+				return err
+			})).
+		Use(Compress(NewCompressOptions()))
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{name: "Should gzip large response given gzip accepted", method: http.MethodGet, path: "/large", acceptEncoding: "gzip, deflate", expectedEncoding: "gzip", expectedBody: "transaction"},
+		{name: "Should deflate large response given deflate preferred", method: http.MethodGet, path: "/large", acceptEncoding: "gzip;q=0.5, deflate", expectedEncoding: "deflate", expectedBody: "transaction"},
+		{name: "Should not compress given small response", method: http.MethodGet, path: "/small", acceptEncoding: "gzip", expectedBody: "small"},
+		{name: "Should not compress given identity only", method: http.MethodGet, path: "/large", acceptEncoding: "gzip;q=0", expectedBody: "transaction"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.expectedEncoding, rec.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+
+			var body io.Reader = rec.Body
+			switch tt.expectedEncoding {
+			case "gzip":
+				reader, err := gzip.NewReader(rec.Body)
+				assert.NoError(t, err)
+				body = reader
+			case "deflate":
+				reader, err := zlib.NewReader(rec.Body)
+				assert.NoError(t, err)
+				body = reader
+			}
+
+			decoded, err := io.ReadAll(body)
+			assert.NoError(t, err)
+			assert.Contains(t, string(decoded), tt.expectedBody)
+		})
+	}
+
+	headTests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{name: "Should announce gzip given head of large response", path: "/large", acceptEncoding: "gzip", expectedEncoding: "gzip"},
+		{name: "Should announce deflate given head of large response with deflate preferred", path: "/large", acceptEncoding: "gzip;q=0.5, deflate", expectedEncoding: "deflate"},
+		{name: "Should not announce encoding given head of small response", path: "/small", acceptEncoding: "gzip"},
+	}
+	for _, tt := range headTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodHead, tt.path, nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.expectedEncoding, rec.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+			assert.Empty(t, rec.Body.String())
+		})
+	}
+
+	t.Run("Should decompress zlib wrapped deflate request body", func(t *testing.T) {
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		_, _ = writer.Write([]byte(`{"title":"Pharmacy"}`))
+		_ = writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", &compressed)
+		req.Header.Set("Content-Encoding", "deflate")
+		rec := httptest.NewRecorder()
+
+		restServer.ServeHTTP(rec, req)
+
+		assert.Equal(t, `{"title":"Pharmacy"}`, uploaded)
+	})
+
+	t.Run("Should decompress gzip request body", func(t *testing.T) {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		_, _ = writer.Write([]byte(`{"title":"Supermarket"}`))
+		_ = writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", &compressed)
+		req.Header.Set("Content-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		restServer.ServeHTTP(rec, req)
+
+		assert.Equal(t, `{"title":"Supermarket"}`, uploaded)
+	})
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/limiter"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ConcurrencyOptions struct {
+	Global     *limiter.Limiter
+	RetryAfter time.Duration
+	routes     map[string]*limiter.Limiter
+}
+
+func NewConcurrencyOptions(global *limiter.Limiter) *ConcurrencyOptions {
+	return &ConcurrencyOptions{
+		Global:     global,
+		RetryAfter: time.Second,
+		routes:     map[string]*limiter.Limiter{},
+	}
+}
+
+func (o *ConcurrencyOptions) WithRoute(method string, pattern string, routeLimiter *limiter.Limiter) *ConcurrencyOptions {
+	o.routes[routeKey(method, pattern)] = routeLimiter
+	return o
+}
+
+func ConcurrencyLimit(options *ConcurrencyOptions) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) (err error) {
+			limiters := make([]*limiter.Limiter, 0, 2)
+			if options.Global != nil {
+				limiters = append(limiters, options.Global)
+			}
+			if routeLimiter, ok := options.routes[routeKey(ctx.Request().Method, ctx.Route())]; ok {
+				limiters = append(limiters, routeLimiter)
+			}
+
+			releases := make([]limiter.ReleaseFunc, 0, len(limiters))
+			release := func(dropped bool) {
+				for _, r := range releases {
+					r(dropped)
+				}
+			}
+
+			for _, l := range limiters {
+				r, err := l.Acquire(ctx.ReqCtx())
+				if err != nil {
+					// Slots already held were given up to shedding, an overload
+					// signal rather than a completed request.
+					release(true)
+					ctx.Logger().Debug(ctx.ReqCtx(), fmt.Sprintf("shedding %v %v on %v limiter: %v",
+						ctx.Request().Method, ctx.Request().URL.Path, l.Name(), err))
+					return options.overloaded(ctx)
+				}
+				releases = append(releases, r)
+			}
+
+			// Deferred so a panicking handler, recovered by an outer middleware,
+			// still returns its slots. A panic counts as a dropped request.
+			completed := false
+			defer func() {
+				release(!completed || isOverloadError(err))
+			}()
+
+			err = next(ctx)
+			completed = true
+			return err
+		}
+	}
+}
+
+func (o *ConcurrencyOptions) overloaded(ctx server.IHttpContext) error {
+	if o.RetryAfter > 0 {
+		seconds := int(o.RetryAfter.Round(time.Second).Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		ctx.Writer().Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	return exception.NewServiceUnavailableProblem("The server is overloaded, please retry later")
+}
+
+func isOverloadError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var problem exception.Problem
+	if errors.As(err, &problem) {
+		return problem.Code == http.StatusServiceUnavailable || problem.Code == http.StatusGatewayTimeout
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/limiter"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	global := limiter.New("global", limiter.NewOptions(1).WithQueue(0, 10*time.Millisecond))
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	slow := func(ctx server.IHttpContext) error {
+		entered <- struct{}{}
+		<-unblock
+		return ctx.WriteResponse(http.StatusOK, "slow")
+	}
+	fast := func(ctx server.IHttpContext) error {
+		return ctx.WriteResponse(http.StatusOK, "fast")
+	}
+	panicking := func(ctx server.IHttpContext) error {
+		panic("boom")
+	}
+
+	recovery := func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = exception.NewInternalServerError()
+				}
+			}()
+			return next(ctx)
+		}
+	}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/slow", slow).
+			Get("/fast", fast).
+			Get("/panic", panicking)).
+		Use(recovery).
+		Use(ConcurrencyLimit(NewConcurrencyOptions(global)))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	t.Run("Should return 503 with Retry-After given the limit is reached", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- serve("/slow") }()
+		<-entered
+
+		rec := serve("/fast")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+		close(unblock)
+		assert.Equal(t, http.StatusOK, (<-done).Code)
+		assert.Equal(t, http.StatusOK, serve("/fast").Code)
+	})
+
+	t.Run("Should release the slot given a panicking handler", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, serve("/panic").Code)
+		assert.Equal(t, http.StatusOK, serve("/fast").Code)
+	})
+}
+
+func TestConcurrencyLimit_RouteShedding(t *testing.T) {
+	global := limiter.New("global", limiter.NewOptions(2).
+		WithQueue(0, 10*time.Millisecond).
+		WithAlgorithm(limiter.NewAIMD(1, 10, time.Second)))
+	route := limiter.New("reports", limiter.NewOptions(1).WithQueue(0, 10*time.Millisecond))
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	reports := func(ctx server.IHttpContext) error {
+		entered <- struct{}{}
+		<-unblock
+		return ctx.WriteResponse(http.StatusOK, "reports")
+	}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().Get("/reports", reports)).
+		Use(ConcurrencyLimit(NewConcurrencyOptions(global).WithRoute(http.MethodGet, "/reports", route)))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		restServer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reports", nil))
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, 1, global.Limit(), "a request shed by the route limiter must lower the global limit")
+
+	close(unblock)
+	<-done
+}
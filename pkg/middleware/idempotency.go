@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/apikey"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/idempotency"
+	"github.com/yurikilian/bills/pkg/server"
+	"io"
+	"net/http"
+	"time"
+)
+
+type IdempotencyOptions struct {
+	Store    idempotency.Store
+	Header   string
+	TTL      time.Duration
+	Methods  []string
+	Required bool
+	// Caller scopes keys so two callers can never replay each other responses.
+	// Keys sent by a request without caller, an empty string, are rejected.
+	// Defaults to APIKeyCaller, so only authenticated requests are idempotent.
+	Caller func(ctx server.IHttpContext) string
+	// MaxBodyBytes bounds the body read to fingerprint the request.
+	MaxBodyBytes int64
+}
+
+func NewIdempotencyOptions(store idempotency.Store) *IdempotencyOptions {
+	return &IdempotencyOptions{
+		Store:        store,
+		Header:       "Idempotency-Key",
+		TTL:          24 * time.Hour,
+		Methods:      []string{http.MethodPost, http.MethodPatch},
+		Caller:       APIKeyCaller,
+		MaxBodyBytes: 1 << 20,
+	}
+}
+
+func Idempotency(options *IdempotencyOptions) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			if !containsMethod(options.Methods, ctx.Request().Method) {
+				return next(ctx)
+			}
+
+			idempotencyKey := ctx.Request().Header.Get(options.Header)
+			if len(idempotencyKey) == 0 {
+				if options.Required {
+					return exception.NewBadRequestProblem(fmt.Sprintf("The %v header is required", options.Header))
+				}
+				return next(ctx)
+			}
+
+			caller := options.Caller(ctx)
+			if len(caller) == 0 {
+				return exception.NewBadRequestProblem(fmt.Sprintf("The %v header requires an identified caller", options.Header))
+			}
+
+			fingerprint, err := fingerprintRequest(ctx, options.MaxBodyBytes)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					return exception.NewPayloadTooLargeProblem(fmt.Sprintf("The body exceeds %v bytes", maxBytesErr.Limit))
+				}
+				return exception.NewMalformedRequestProblem()
+			}
+
+			key := caller + ":" + idempotencyKey
+			record, created, err := options.Store.Begin(ctx.ReqCtx(), key, fingerprint, options.TTL)
+			if err != nil {
+				return exception.NewInternalServerError(err.Error())
+			}
+
+			if !created {
+				return replay(ctx, record, fingerprint)
+			}
+
+			return options.process(ctx, next, key)
+		}
+	}
+}
+
+func (o *IdempotencyOptions) process(ctx server.IHttpContext, next server.HttpMethodHandler, key string) (err error) {
+	completed := false
+	defer func() {
+		if !completed {
+			if rErr := o.Store.Release(ctx.ReqCtx(), key); rErr != nil {
+				ctx.Logger().Error(ctx.ReqCtx(), fmt.Sprintf("could not release idempotency key: %v", rErr))
+			}
+		}
+	}()
+
+	writer := ctx.Writer()
+	recorder := newResponseRecorder(writer)
+	ctx.SetWriter(recorder)
+	err = next(ctx)
+	ctx.SetWriter(writer)
+
+	recorded := recorder.response()
+	response := &idempotency.Response{StatusCode: recorded.StatusCode, Header: recorded.Header, Body: recorded.Body}
+	if err != nil {
+		var problem exception.Problem
+		if !errors.As(err, &problem) || problem.Code >= http.StatusInternalServerError {
+			return err
+		}
+
+		body, mErr := json.Marshal(problem)
+		if mErr != nil {
+			return err
+		}
+		response = &idempotency.Response{StatusCode: problem.Code, Header: http.Header{}, Body: body}
+	}
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		return err
+	}
+
+	if cErr := o.Store.Complete(ctx.ReqCtx(), key, response); cErr != nil {
+		ctx.Logger().Error(ctx.ReqCtx(), fmt.Sprintf("could not store idempotent response: %v", cErr))
+		return err
+	}
+	completed = true
+	return err
+}
+
+func replay(ctx server.IHttpContext, record *idempotency.Record, fingerprint string) error {
+	if record.Fingerprint != fingerprint && len(record.Fingerprint) > 0 {
+		return exception.NewUnprocessableEntityProblem("The idempotency key was already used with a different payload")
+	}
+
+	if record.Status == idempotency.InFlight {
+		return exception.NewConflictProblem("A request with the same idempotency key is still being processed")
+	}
+
+	header := ctx.Writer().Header()
+	for name, values := range record.Response.Header {
+		header[name] = values
+	}
+	header.Set("Idempotent-Replayed", "true")
+
+	ctx.Writer().WriteHeader(record.Response.StatusCode)
+	_, err := ctx.Writer().Write(record.Response.Body)
+	return err
+}
+
+func fingerprintRequest(ctx server.IHttpContext, maxBodyBytes int64) (string, error) {
+	request := ctx.Request()
+
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.URL.Path + "\n"))
+
+	if request.Body != nil {
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer(), request.Body, maxBodyBytes))
+		if err != nil {
+			return "", err
+		}
+		request.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// APIKeyCaller identifies callers by API key. Unauthenticated requests have no
+// caller.
+func APIKeyCaller(ctx server.IHttpContext) string {
+	if key, ok := apikey.FromContext(ctx.ReqCtx()); ok {
+		return key.Prefix
+	}
+	return ""
+}
+
+// ClientIPCaller identifies callers by API key, falling back to the client IP
+// for unauthenticated requests. Only opt in when the client IP is trustworthy:
+// behind a proxy missing from the server TrustedProxies every anonymous client
+// shares the proxy IP and can replay the responses of the others.
+func ClientIPCaller(ctx server.IHttpContext) string {
+	if caller := APIKeyCaller(ctx); len(caller) > 0 {
+		return caller
+	}
+	if ip := ctx.ClientIP(); ip.IsValid() {
+		return "ip:" + ip.String()
+	}
+	return ""
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
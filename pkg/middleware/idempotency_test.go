@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/idempotency"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	created := 0
+	handler := func(ctx server.IHttpContext) error {
+		created++
+		return ctx.WriteResponse(http.StatusCreated, created)
+	}
+
+	store := idempotency.NewInMemoryStore()
+	_, _, err := store.Begin(context.Background(), "ip:192.0.2.1:in-flight", "", time.Minute)
+	assert.NoError(t, err)
+
+	options := NewIdempotencyOptions(store)
+	options.Caller = ClientIPCaller
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().POST("/transactions", handler)).
+		Use(Idempotency(options))
+
+	tests := []struct {
+		name               string
+		key                string
+		body               string
+		expectedStatusCode int
+		expectedBody       string
+		expectedReplayed   bool
+	}{
+		{name: "Should process request given new key", key: "first", body: `{"price":1}`, expectedStatusCode: http.StatusCreated, expectedBody: "1\n"},
+		{name: "Should replay response given retried key with same payload", key: "first", body: `{"price":1}`, expectedStatusCode: http.StatusCreated, expectedBody: "1\n", expectedReplayed: true},
+		{name: "Should return unprocessable entity given retried key with different payload", key: "first", body: `{"price":2}`, expectedStatusCode: http.StatusUnprocessableEntity},
+		{name: "Should return conflict given key still in flight", key: "in-flight", body: `{"price":1}`, expectedStatusCode: http.StatusConflict},
+		{name: "Should process request given no key", body: `{"price":1}`, expectedStatusCode: http.StatusCreated, expectedBody: "2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(tt.body))
+			if len(tt.key) > 0 {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if len(tt.expectedBody) > 0 {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+			}
+			assert.Equal(t, tt.expectedReplayed, rec.Header().Get("Idempotent-Replayed") == "true")
+		})
+	}
+}
+
+func TestIdempotency_Callers(t *testing.T) {
+	created := 0
+	handler := func(ctx server.IHttpContext) error {
+		created++
+		return ctx.WriteResponse(http.StatusCreated, created)
+	}
+
+	options := NewIdempotencyOptions(idempotency.NewInMemoryStore())
+	options.Caller = ClientIPCaller
+	options.MaxBodyBytes = 16
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().POST("/transactions", handler)).
+		Use(Idempotency(options))
+
+	anonymousServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().POST("/transactions", handler)).
+		Use(Idempotency(NewIdempotencyOptions(idempotency.NewInMemoryStore())))
+
+	tests := []struct {
+		name               string
+		server             *server.RestServer
+		remoteAddr         string
+		body               string
+		expectedStatusCode int
+		expectedBody       string
+		expectedReplayed   bool
+	}{
+		{name: "Should process request given first caller", server: restServer, remoteAddr: "192.0.2.1:1234", body: `{"price":1}`, expectedStatusCode: http.StatusCreated, expectedBody: "1\n"},
+		{name: "Should not replay response given same key from another caller", server: restServer, remoteAddr: "192.0.2.2:1234", body: `{"price":1}`, expectedStatusCode: http.StatusCreated, expectedBody: "2\n"},
+		{name: "Should replay response given same key from same caller", server: restServer, remoteAddr: "192.0.2.1:4321", body: `{"price":1}`, expectedStatusCode: http.StatusCreated, expectedBody: "1\n", expectedReplayed: true},
+		{name: "Should return payload too large given body over the limit", server: restServer, remoteAddr: "192.0.2.3:1234", body: `{"price":1,"title":"too large"}`, expectedStatusCode: http.StatusRequestEntityTooLarge},
+		{name: "Should return bad request given no api key by default", server: anonymousServer, remoteAddr: "192.0.2.1:1234", body: `{"price":1}`, expectedStatusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(tt.body))
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Idempotency-Key", "shared")
+			rec := httptest.NewRecorder()
+
+			tt.server.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if len(tt.expectedBody) > 0 {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+			}
+			assert.Equal(t, tt.expectedReplayed, rec.Header().Get("Idempotent-Replayed") == "true")
+		})
+	}
+}
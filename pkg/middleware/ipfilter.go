@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/ipfilter"
+	"github.com/yurikilian/bills/pkg/server"
+)
+
+func IPFilter(filter *ipfilter.Filter) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			clientIP := ctx.ClientIP()
+			if !clientIP.IsValid() || !filter.Allows(clientIP) {
+				ctx.Logger().Debug(ctx.ReqCtx(), fmt.Sprintf("rejecting request from %v", clientIP))
+				return exception.NewForbiddenProblem("The client address is not allowed")
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/ipfilter"
+	"github.com/yurikilian/bills/pkg/server"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	rules, err := ipfilter.ParseRules(strings.NewReader("allow 198.51.100.0/24\nallow 127.0.0.1\ndeny 198.51.100.9\n"))
+	assert.NoError(t, err)
+
+	handler := func(ctx server.IHttpContext) error {
+		return ctx.WriteResponse(http.StatusOK, "allowed")
+	}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog()).
+		WithTrustedProxies("10.0.0.0/8")).
+		Router(server.NewRestRouter().Get("/transactions", handler)).
+		Use(IPFilter(ipfilter.NewFilter(rules)))
+
+	tests := []struct {
+		name               string
+		remoteAddr         string
+		unix               bool
+		headers            map[string]string
+		expectedStatusCode int
+	}{
+		{name: "Should allow request given allowed remote address", remoteAddr: "198.51.100.1:5000", expectedStatusCode: http.StatusOK},
+		{name: "Should reject request given denied remote address", remoteAddr: "198.51.100.9:5000", expectedStatusCode: http.StatusForbidden},
+		{name: "Should reject request given remote address outside allow list", remoteAddr: "203.0.113.7:5000", expectedStatusCode: http.StatusForbidden},
+		{name: "Should allow request given allowed client behind trusted proxy", remoteAddr: "10.0.0.2:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, expectedStatusCode: http.StatusOK},
+		{name: "Should reject request given denied client behind trusted proxy", remoteAddr: "10.0.0.2:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.9"}, expectedStatusCode: http.StatusForbidden},
+		{name: "Should reject request given spoofed Forwarded behind trusted proxy", remoteAddr: "10.0.0.2:5000", headers: map[string]string{"Forwarded": "for=198.51.100.1", "X-Forwarded-For": "203.0.113.7"}, expectedStatusCode: http.StatusForbidden},
+		{name: "Should ignore X-Forwarded-For given untrusted remote address", remoteAddr: "203.0.113.7:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, expectedStatusCode: http.StatusForbidden},
+		{name: "Should allow request given unix socket peer allowed as loopback", remoteAddr: "@", unix: true, expectedStatusCode: http.StatusOK},
+		{name: "Should reject request given unknown hop behind trusted proxy", remoteAddr: "10.0.0.2:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1, unknown"}, expectedStatusCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/transactions", nil)
+			if tt.unix {
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/run/bills.sock", Net: "unix"}))
+			}
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+		})
+	}
+}
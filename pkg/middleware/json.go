@@ -2,24 +2,78 @@ package middleware
 
 import (
 	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/matcher"
 	"github.com/yurikilian/bills/pkg/server"
 	"mime"
+	"net/http"
+	"strings"
 )
 
+type JsonOptions struct {
+	// BodyMethods always require a JSON content type, even when no body is sent.
+	BodyMethods   []string
+	ExcludedPaths []string
+}
+
+func NewJsonOptions() *JsonOptions {
+	return &JsonOptions{
+		BodyMethods: []string{http.MethodPost, http.MethodPut, http.MethodPatch},
+	}
+}
+
 func Json() server.Middleware {
+	return JsonWithOptions(NewJsonOptions())
+}
+
+// JsonWithOptions rejects requests carrying a body, or using a BodyMethod,
+// whose Content-Type is not utf-8 JSON with 415 Unsupported Media Type.
+func JsonWithOptions(options *JsonOptions) server.Middleware {
 	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
 		return func(ctx server.IHttpContext) error {
+			if !options.requiresJson(ctx.Request()) {
+				return next(ctx)
+			}
+
 			contentType := ctx.Request().Header.Get("Content-Type")
-			mediaType, _, err := mime.ParseMediaType(contentType)
+			mediaType, params, err := mime.ParseMediaType(contentType)
 			if err != nil {
 				return exception.NewUnsupportedMediaType("Invalid Content-type")
 			}
 
-			if mediaType != "application/json" {
-				return exception.NewBadRequestProblem("Content-Type header must be application/json")
+			if !isJsonMediaType(mediaType) {
+				return exception.NewUnsupportedMediaType("Content-Type header must be application/json")
+			}
+
+			if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+				return exception.NewUnsupportedMediaType("Content-Type charset must be utf-8")
 			}
 
 			return next(ctx)
 		}
 	}
 }
+
+func (o *JsonOptions) requiresJson(req *http.Request) bool {
+	pathParts := strings.Split(req.URL.Path, "/")
+	for _, pattern := range o.ExcludedPaths {
+		if matcher.MatchPath(pathParts, pattern) {
+			return false
+		}
+	}
+
+	if req.ContentLength != 0 {
+		return true
+	}
+
+	for _, method := range o.BodyMethods {
+		if req.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+func isJsonMediaType(mediaType string) bool {
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJson(t *testing.T) {
+	handler := func(ctx server.IHttpContext) error {
+		return ctx.WriteResponse(http.StatusOK, "ok")
+	}
+
+	options := NewJsonOptions()
+	options.ExcludedPaths = []string{"/webhooks"}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/transactions", handler).
+			POST("/transactions", handler).
+			POST("/webhooks", handler)).
+		Use(JsonWithOptions(options))
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		contentType        string
+		body               string
+		expectedStatusCode int
+	}{
+		{name: "Should accept given application/json", method: http.MethodPost, path: "/transactions", contentType: "application/json", body: `{}`, expectedStatusCode: http.StatusOK},
+		{name: "Should accept given +json suffix type", method: http.MethodPost, path: "/transactions", contentType: "application/merge-patch+json", body: `{}`, expectedStatusCode: http.StatusOK},
+		{name: "Should accept given utf-8 charset", method: http.MethodPost, path: "/transactions", contentType: "application/json; charset=UTF-8", body: `{}`, expectedStatusCode: http.StatusOK},
+		{name: "Should return unsupported media type given other charset", method: http.MethodPost, path: "/transactions", contentType: "application/json; charset=latin1", body: `{}`, expectedStatusCode: http.StatusUnsupportedMediaType},
+		{name: "Should return unsupported media type given other media type", method: http.MethodPost, path: "/transactions", contentType: "text/plain", body: `{}`, expectedStatusCode: http.StatusUnsupportedMediaType},
+		{name: "Should return unsupported media type given +json suffix outside application", method: http.MethodPost, path: "/transactions", contentType: "text/x+json", body: `{}`, expectedStatusCode: http.StatusUnsupportedMediaType},
+		{name: "Should return unsupported media type given unparsable content type", method: http.MethodPost, path: "/transactions", contentType: "application/json; charset", body: `{}`, expectedStatusCode: http.StatusUnsupportedMediaType},
+		{name: "Should return unsupported media type given body method without content type", method: http.MethodPost, path: "/transactions", expectedStatusCode: http.StatusUnsupportedMediaType},
+		{name: "Should accept given bodyless method without content type", method: http.MethodGet, path: "/transactions", expectedStatusCode: http.StatusOK},
+		{name: "Should return unsupported media type given bodyless method with non json body", method: http.MethodGet, path: "/transactions", contentType: "text/plain", body: `id=1`, expectedStatusCode: http.StatusUnsupportedMediaType},
+		{name: "Should accept given excluded path", method: http.MethodPost, path: "/webhooks", contentType: "application/x-www-form-urlencoded", body: `a=1`, expectedStatusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if len(tt.contentType) > 0 {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+		})
+	}
+}
@@ -3,13 +3,16 @@ package middleware
 import (
 	"github.com/yurikilian/bills/pkg/server"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 func Otel() server.Middleware {
 	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
 		return func(rCtx server.IHttpContext) error {
+			// Continue the trace of the caller, e.g. the client package.
+			parentCtx := otel.GetTextMapPropagator().Extract(rCtx.Request().Context(), propagation.HeaderCarrier(rCtx.Request().Header))
 			traceCtx, span := otel.Tracer("").
-				Start(rCtx.Request().Context(), rCtx.Request().URL.Path)
+				Start(parentCtx, rCtx.Request().URL.Path)
 
 			newReq := rCtx.Request().WithContext(traceCtx)
 			rCtx.SetRequest(newReq)
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+type recordedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// responseRecorder forwards the response to the client while keeping a copy
+// of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w}
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) response() *recordedResponse {
+	statusCode := r.statusCode
+	header := r.header
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+		header = r.ResponseWriter.Header().Clone()
+	}
+
+	return &recordedResponse{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       r.body.Bytes(),
+	}
+}
+
+func (r *responseRecorder) tags(tagHeader string) []string {
+	tags := make([]string, 0)
+	for _, value := range r.response().Header.Values(tagHeader) {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); len(tag) > 0 {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/yurikilian/bills/pkg/di"
+	"github.com/yurikilian/bills/pkg/server"
+)
+
+// RequestScope opens a dependency scope per request, so handlers resolve
+// scoped values with di.ResolveRequest. The scope is closed after the handler.
+func RequestScope(container *di.Container) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			scope := container.NewScope()
+			defer func() {
+				if err := scope.Close(); err != nil {
+					ctx.Logger().Error(ctx.ReqCtx(), "could not close request scope: "+err.Error())
+				}
+			}()
+
+			ctx.SetRequest(ctx.Request().WithContext(di.WithScope(ctx.ReqCtx(), scope)))
+			return next(ctx)
+		}
+	}
+}
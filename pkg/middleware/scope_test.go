@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/di"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type requestID struct {
+	value  int
+	closed bool
+}
+
+func (r *requestID) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestRequestScope(t *testing.T) {
+	next := 0
+	ids := make([]*requestID, 0)
+
+	container := di.New()
+	di.Provide(container, di.Scoped, func(s *di.Scope) (*requestID, error) {
+		next++
+		id := &requestID{value: next}
+		ids = append(ids, id)
+		return id, nil
+	})
+
+	handler := func(ctx server.IHttpContext) error {
+		first, err := di.ResolveRequest[*requestID](ctx)
+		if err != nil {
+			return err
+		}
+		second, _ := di.ResolveRequest[*requestID](ctx)
+		return ctx.WriteResponse(http.StatusOK, fmt.Sprintf("%v-%v", first.value, second.value))
+	}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().Get("/", handler)).
+		Use(RequestScope(container))
+
+	for _, want := range []string{"\"1-1\"\n", "\"2-2\"\n"} {
+		rec := httptest.NewRecorder()
+		restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, want, rec.Body.String())
+	}
+
+	assert.Len(t, ids, 2)
+	for _, id := range ids {
+		assert.True(t, id.closed)
+	}
+}
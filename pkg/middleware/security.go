@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/yurikilian/bills/pkg/csp"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"strings"
+)
+
+type SecurityHeadersOptions struct {
+	StrictTransportSecurity string
+	ContentTypeOptions      string
+	FrameOptions            string
+	ReferrerPolicy          string
+	PermissionsPolicy       string
+	ContentSecurityPolicy   *csp.Policy
+	groups                  []securityHeadersGroup
+}
+
+type securityHeadersGroup struct {
+	prefix  string
+	options *SecurityHeadersOptions
+}
+
+// NewSecurityHeadersOptions returns defaults for a JSON API, which never
+// renders documents and so can deny every resource type.
+func NewSecurityHeadersOptions() *SecurityHeadersOptions {
+	return &SecurityHeadersOptions{
+		StrictTransportSecurity: "max-age=63072000; includeSubDomains",
+		ContentTypeOptions:      "nosniff",
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "no-referrer",
+		PermissionsPolicy:       "accelerometer=(), camera=(), geolocation=(), gyroscope=(), microphone=(), payment=(), usb=()",
+		ContentSecurityPolicy:   csp.NewPolicy().DefaultSrc(csp.None).FrameAncestors(csp.None),
+	}
+}
+
+// WithGroup overrides the options for every path under prefix, matched on
+// whole segments. The longest matching prefix wins.
+func (o *SecurityHeadersOptions) WithGroup(prefix string, options *SecurityHeadersOptions) *SecurityHeadersOptions {
+	o.groups = append(o.groups, securityHeadersGroup{prefix: prefix, options: options})
+	return o
+}
+
+func (o *SecurityHeadersOptions) forPath(path string) *SecurityHeadersOptions {
+	selected := o
+	longest := -1
+	for _, group := range o.groups {
+		if hasPathPrefix(path, group.prefix) && len(group.prefix) > longest {
+			selected = group.options
+			longest = len(group.prefix)
+		}
+	}
+	return selected
+}
+
+// hasPathPrefix matches whole segments, so /admin matches /admin and
+// /admin/users but not /administrator.
+func hasPathPrefix(path string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return len(prefix) == 0 || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func SecurityHeaders(options *SecurityHeadersOptions) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			selected := options.forPath(ctx.Request().URL.Path)
+			header := ctx.Writer().Header()
+
+			setIfNotEmpty(header.Set, "Strict-Transport-Security", selected.StrictTransportSecurity)
+			setIfNotEmpty(header.Set, "X-Content-Type-Options", selected.ContentTypeOptions)
+			setIfNotEmpty(header.Set, "X-Frame-Options", selected.FrameOptions)
+			setIfNotEmpty(header.Set, "Referrer-Policy", selected.ReferrerPolicy)
+			setIfNotEmpty(header.Set, "Permissions-Policy", selected.PermissionsPolicy)
+
+			if selected.ContentSecurityPolicy != nil {
+				var nonce string
+				if selected.ContentSecurityPolicy.UsesNonce() {
+					var err error
+					if nonce, err = newNonce(); err != nil {
+						return exception.NewInternalServerError(err.Error())
+					}
+					ctx.SetCspNonce(nonce)
+				}
+				header.Set("Content-Security-Policy", selected.ContentSecurityPolicy.Build(nonce))
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+func setIfNotEmpty(set func(key string, value string), key string, value string) {
+	if len(value) > 0 {
+		set(key, value)
+	}
+}
+
+func newNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(nonce), nil
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/csp"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := func(ctx server.IHttpContext) error {
+		return ctx.WriteResponse(http.StatusOK, ctx.CspNonce())
+	}
+
+	admin := NewSecurityHeadersOptions()
+	admin.FrameOptions = "SAMEORIGIN"
+	admin.ContentSecurityPolicy = csp.NewPolicy().DefaultSrc(csp.Self).ScriptSrc(csp.Self, csp.Nonce)
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/transactions", handler).
+			Get("/admin", handler).
+			Get("/admin/users", handler).
+			Get("/administrator", handler)).
+		Use(SecurityHeaders(NewSecurityHeadersOptions().WithGroup("/admin", admin)))
+
+	defaultPolicy := "default-src 'none'; frame-ancestors 'none'"
+
+	tests := []struct {
+		name                 string
+		path                 string
+		expectedFrameOptions string
+		expectedPolicy       string
+		expectedNonce        bool
+	}{
+		{name: "Should emit default policy given path outside groups", path: "/transactions", expectedFrameOptions: "DENY", expectedPolicy: defaultPolicy},
+		{name: "Should emit group policy given group path", path: "/admin", expectedFrameOptions: "SAMEORIGIN", expectedNonce: true},
+		{name: "Should emit group policy given path under group", path: "/admin/users", expectedFrameOptions: "SAMEORIGIN", expectedNonce: true},
+		{name: "Should emit default policy given path sharing group prefix", path: "/administrator", expectedFrameOptions: "DENY", expectedPolicy: defaultPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "max-age=63072000; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+			assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, tt.expectedFrameOptions, rec.Header().Get("X-Frame-Options"))
+			assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+
+			policy := rec.Header().Get("Content-Security-Policy")
+			if !tt.expectedNonce {
+				assert.Equal(t, tt.expectedPolicy, policy)
+				assert.Equal(t, "\"\"\n", rec.Body.String())
+				return
+			}
+
+			nonce := strings.Trim(strings.TrimSpace(rec.Body.String()), "\"")
+			assert.NotEmpty(t, nonce)
+			assert.Equal(t, "default-src 'self'; script-src 'self' 'nonce-"+nonce+"'", policy)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type TimeoutOptions struct {
+	Default time.Duration
+	// StatusCode is either http.StatusServiceUnavailable or http.StatusGatewayTimeout.
+	StatusCode int
+	routes     map[string]time.Duration
+}
+
+func NewTimeoutOptions(defaultTimeout time.Duration) *TimeoutOptions {
+	return &TimeoutOptions{
+		Default:    defaultTimeout,
+		StatusCode: http.StatusServiceUnavailable,
+		routes:     map[string]time.Duration{},
+	}
+}
+
+// WithRoute overrides the default timeout for a registered route pattern. A
+// zero timeout disables the deadline for that route.
+func (o *TimeoutOptions) WithRoute(method string, pattern string, timeout time.Duration) *TimeoutOptions {
+	o.routes[routeKey(method, pattern)] = timeout
+	return o
+}
+
+func (o *TimeoutOptions) timeoutFor(ctx server.IHttpContext) time.Duration {
+	if timeout, ok := o.routes[routeKey(ctx.Request().Method, ctx.Route())]; ok {
+		return timeout
+	}
+	return o.Default
+}
+
+func (o *TimeoutOptions) problem(timeout time.Duration) exception.Problem {
+	message := fmt.Sprintf("The request did not complete within %v", timeout)
+	if o.StatusCode == http.StatusGatewayTimeout {
+		return exception.NewGatewayTimeoutProblem(message)
+	}
+	return exception.NewServiceUnavailableProblem(message)
+}
+
+func Timeout(options *TimeoutOptions) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			timeout := options.timeoutFor(ctx)
+			if timeout <= 0 {
+				return next(ctx)
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx.ReqCtx(), timeout)
+			defer cancel()
+
+			// The handler runs on a detached context so a late handler never
+			// touches the pooled context once the response is answered.
+			writer := newTimeoutWriter(ctx.Writer())
+			handlerCtx := ctx.Clone()
+			handlerCtx.SetWriter(writer)
+			handlerCtx.SetRequest(ctx.Request().WithContext(reqCtx))
+
+			done := make(chan error, 1)
+			panicked := make(chan interface{}, 1)
+			started := time.Now()
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				done <- next(handlerCtx)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case err := <-done:
+				writer.flush()
+				return err
+			case <-reqCtx.Done():
+				committed := writer.abandon()
+				message := fmt.Sprintf("abandoning %v %v after %v: %v",
+					ctx.Request().Method, ctx.Request().URL.Path, time.Since(started), reqCtx.Err())
+
+				if !errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+					// The client went away, there is nobody to answer.
+					ctx.Logger().Debug(ctx.ReqCtx(), message)
+					if committed {
+						return nil
+					}
+					return exception.NewClientClosedRequestProblem()
+				}
+
+				ctx.Logger().Warn(ctx.ReqCtx(), message)
+				if committed {
+					// A flushed response can no longer be replaced.
+					return nil
+				}
+				return options.problem(timeout)
+			}
+		}
+	}
+}
+
+func routeKey(method string, pattern string) string {
+	return method + " " + pattern
+}
+
+// timeoutWriter buffers the handler response until it either completes, when
+// it is copied to the real writer, or is abandoned, when further writes fail.
+// Flush commits the buffered response, so streaming handlers still flush, but
+// once committed a timeout can only cut the response short.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	dst         http.ResponseWriter
+	header      http.Header
+	body        bytes.Buffer
+	code        int
+	wroteHeader bool
+	committed   bool
+	abandoned   bool
+}
+
+func newTimeoutWriter(dst http.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		dst:    dst,
+		header: make(http.Header),
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.abandoned {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.wroteHeader {
+		w.writeHeaderLocked(http.StatusOK)
+	}
+	if w.committed {
+		return w.dst.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+// Flush commits the response written so far and flushes it to the client.
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.abandoned {
+		return
+	}
+	if !w.wroteHeader {
+		w.writeHeaderLocked(http.StatusOK)
+	}
+	w.commitLocked()
+	if flusher, ok := w.dst.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.abandoned || w.wroteHeader {
+		return
+	}
+	w.writeHeaderLocked(code)
+}
+
+func (w *timeoutWriter) writeHeaderLocked(code int) {
+	w.wroteHeader = true
+	w.code = code
+}
+
+func (w *timeoutWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.wroteHeader {
+		if !w.committed {
+			w.copyHeaderLocked()
+		}
+		return
+	}
+	w.commitLocked()
+}
+
+func (w *timeoutWriter) commitLocked() {
+	if !w.committed {
+		w.copyHeaderLocked()
+		w.dst.WriteHeader(w.code)
+		w.committed = true
+	}
+	if w.body.Len() > 0 {
+		_, _ = w.dst.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+func (w *timeoutWriter) copyHeaderLocked() {
+	dst := w.dst.Header()
+	for key, values := range w.header {
+		dst[key] = values
+	}
+}
+
+// abandon fails further writes and reports whether the response was already
+// committed by a Flush.
+func (w *timeoutWriter) abandon() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.abandoned = true
+	return w.committed
+}
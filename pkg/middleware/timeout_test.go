@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	lateWrite := make(chan error, 1)
+
+	fast := func(ctx server.IHttpContext) error {
+		return ctx.WriteResponse(http.StatusOK, "fast")
+	}
+	slow := func(ctx server.IHttpContext) error {
+		<-ctx.ReqCtx().Done()
+		time.Sleep(10 * time.Millisecond)
+		err := ctx.WriteResponse(http.StatusOK, "late")
+		lateWrite <- err
+		return err
+	}
+	// medium outlives the /reports override but not the default timeout.
+	medium := func(ctx server.IHttpContext) error {
+		select {
+		case <-ctx.ReqCtx().Done():
+		case <-time.After(40 * time.Millisecond):
+		}
+		err := ctx.WriteResponse(http.StatusOK, "medium")
+		lateWrite <- err
+		return err
+	}
+
+	options := NewTimeoutOptions(100*time.Millisecond).
+		WithRoute(http.MethodGet, "/reports", 20*time.Millisecond)
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/fast", fast).
+			Get("/slow", slow).
+			Get("/medium", medium).
+			Get("/reports", medium)).
+		Use(Timeout(options))
+
+	tests := []struct {
+		name               string
+		path               string
+		expectedStatusCode int
+		expectsLateWrite   bool
+		expectsWrite       bool
+	}{
+		{name: "Should write handler response given handler finished in time", path: "/fast", expectedStatusCode: http.StatusOK},
+		{name: "Should return service unavailable given handler exceeded default timeout", path: "/slow", expectedStatusCode: http.StatusServiceUnavailable, expectsLateWrite: true},
+		{name: "Should write handler response given handler finished within default timeout", path: "/medium", expectedStatusCode: http.StatusOK, expectsWrite: true},
+		{name: "Should return service unavailable given same handler exceeded route timeout", path: "/reports", expectedStatusCode: http.StatusServiceUnavailable, expectsLateWrite: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+
+			if tt.expectsWrite {
+				assert.NoError(t, <-lateWrite)
+				assert.Equal(t, "\"medium\"\n", rec.Body.String())
+			}
+			if tt.expectsLateWrite {
+				body := rec.Body.String()
+				assert.EqualError(t, <-lateWrite, http.ErrHandlerTimeout.Error())
+				assert.Equal(t, body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTimeout_ClientClosedRequest(t *testing.T) {
+	handler := func(ctx server.IHttpContext) error {
+		<-ctx.ReqCtx().Done()
+		return ctx.ReqCtx().Err()
+	}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().Get("/slow", handler)).
+		Use(Timeout(NewTimeoutOptions(time.Second)))
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(reqCtx))
+
+	assert.Equal(t, exception.StatusClientClosedRequest, rec.Code)
+}
+
+func TestTimeout_Flush(t *testing.T) {
+	handler := func(ctx server.IHttpContext) error {
+		ctx.Writer().Header().Set("Content-Type", "text/event-stream")
+		_, _ = ctx.Writer().Write([]byte("data: first\n\n"))
+		ctx.Writer().(http.Flusher).Flush()
+
+		<-ctx.ReqCtx().Done()
+		_, err := ctx.Writer().Write([]byte("data: late\n\n"))
+		return err
+	}
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().Get("/events", handler)).
+		Use(Timeout(NewTimeoutOptions(50 * time.Millisecond)))
+
+	rec := httptest.NewRecorder()
+	restServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "data: first\n\n", rec.Body.String())
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WebhookOptions struct {
+	// Secrets holds every active secret, so a rotation can add the new secret
+	// before the provider switches to it.
+	Secrets         [][]byte
+	SignatureHeader string
+	TimestampHeader string
+	Tolerance       time.Duration
+	MaxBodyBytes    int64
+	now             func() time.Time
+}
+
+func NewWebhookOptions(secrets ...string) *WebhookOptions {
+	options := &WebhookOptions{
+		SignatureHeader: "X-Signature",
+		TimestampHeader: "X-Signature-Timestamp",
+		Tolerance:       5 * time.Minute,
+		MaxBodyBytes:    1 << 20,
+		now:             time.Now,
+	}
+	for _, secret := range secrets {
+		options.Secrets = append(options.Secrets, []byte(secret))
+	}
+	return options
+}
+
+// SignWebhook returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>".
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func WebhookSignature(options *WebhookOptions) server.Middleware {
+	return func(next server.HttpMethodHandler) server.HttpMethodHandler {
+		return func(ctx server.IHttpContext) error {
+			request := ctx.Request()
+
+			timestamp := request.Header.Get(options.TimestampHeader)
+			signatures := request.Header.Get(options.SignatureHeader)
+			if len(timestamp) == 0 || len(signatures) == 0 {
+				return exception.NewUnauthorizedProblem("The webhook signature is missing")
+			}
+
+			seconds, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return exception.NewUnauthorizedProblem("The webhook timestamp is invalid")
+			}
+
+			age := options.now().Sub(time.Unix(seconds, 0))
+			if age > options.Tolerance || age < -options.Tolerance {
+				return exception.NewUnauthorizedProblem("The webhook timestamp is outside the tolerance window")
+			}
+
+			body := []byte{}
+			if request.Body != nil {
+				body, err = io.ReadAll(io.LimitReader(request.Body, options.MaxBodyBytes+1))
+				if err != nil {
+					return exception.NewMalformedRequestProblem()
+				}
+				if int64(len(body)) > options.MaxBodyBytes {
+					return exception.NewPayloadTooLargeProblem("The webhook payload is too large")
+				}
+			}
+
+			if !options.verify(timestamp, body, signatures) {
+				return exception.NewUnauthorizedProblem("The webhook signature is invalid")
+			}
+
+			request.Body = io.NopCloser(bytes.NewReader(body))
+			request.ContentLength = int64(len(body))
+			return next(ctx)
+		}
+	}
+}
+
+// verify accepts a comma separated list of signatures, each optionally
+// prefixed with "sha256=" or "v1=".
+func (o *WebhookOptions) verify(timestamp string, body []byte, signatures string) bool {
+	for _, signature := range strings.Split(signatures, ",") {
+		signature = strings.TrimSpace(signature)
+		if _, value, found := strings.Cut(signature, "="); found {
+			signature = value
+		}
+
+		received, err := hex.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+
+		for _, secret := range o.Secrets {
+			expected, _ := hex.DecodeString(SignWebhook(secret, timestamp, body))
+			if hmac.Equal(received, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/server"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type paymentEvent struct {
+	Id string `json:"id" validate:"required"`
+}
+
+func TestWebhookSignature(t *testing.T) {
+	now := time.Now()
+	options := NewWebhookOptions("old-secret", "new-secret")
+	options.now = func() time.Time { return now }
+
+	var received paymentEvent
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().POST("/webhooks/payments", func(ctx server.IHttpContext) error {
+			if err := ctx.ReadBody(&received); err != nil {
+				return err
+			}
+			return ctx.WriteResponse(http.StatusNoContent, nil)
+		})).
+		Use(WebhookSignature(options))
+
+	body := `{"id":"evt_1"}`
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name               string
+		timestamp          string
+		signature          string
+		expectedStatusCode int
+	}{
+		{name: "Should accept payload signed with current secret", timestamp: fresh, signature: "sha256=" + SignWebhook([]byte("new-secret"), fresh, []byte(body)), expectedStatusCode: http.StatusNoContent},
+		{name: "Should accept payload signed with rotated secret", timestamp: fresh, signature: SignWebhook([]byte("old-secret"), fresh, []byte(body)), expectedStatusCode: http.StatusNoContent},
+		{name: "Should reject payload signed with unknown secret", timestamp: fresh, signature: SignWebhook([]byte("other"), fresh, []byte(body)), expectedStatusCode: http.StatusUnauthorized},
+		{name: "Should reject stale timestamp", timestamp: stale, signature: SignWebhook([]byte("new-secret"), stale, []byte(body)), expectedStatusCode: http.StatusUnauthorized},
+		{name: "Should reject missing signature", timestamp: fresh, expectedStatusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = paymentEvent{}
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(body))
+			req.Header.Set("X-Signature-Timestamp", tt.timestamp)
+			req.Header.Set("X-Signature", tt.signature)
+			rec := httptest.NewRecorder()
+
+			restServer.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			if tt.expectedStatusCode == http.StatusNoContent {
+				assert.Equal(t, "evt_1", received.Id)
+			}
+		})
+	}
+}
+
+func TestWebhookSignature_PayloadTooLarge(t *testing.T) {
+	options := NewWebhookOptions("secret")
+	options.MaxBodyBytes = 8
+
+	restServer := server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().POST("/webhooks/payments", func(ctx server.IHttpContext) error {
+			return ctx.WriteResponse(http.StatusNoContent, nil)
+		})).
+		Use(WebhookSignature(options))
+
+	body := `{"id":"evt_1"}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature", SignWebhook([]byte("secret"), timestamp, []byte(body)))
+	rec := httptest.NewRecorder()
+
+	restServer.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
@@ -2,17 +2,27 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/yurikilian/bills/pkg/exception"
 	"io"
 )
 
+var errTrailingData = errors.New("unexpected data after the JSON body")
+
+type BindingOptions struct {
+	DisallowUnknownFields bool
+	DisallowTrailingData  bool
+}
+
 type Binder struct {
 	validator *CustomValidator
+	options   BindingOptions
 }
 
-func NewBinder() *Binder {
+func NewBinder(options BindingOptions) *Binder {
 	return &Binder{
 		validator: Validator,
+		options:   options,
 	}
 }
 
@@ -22,35 +32,41 @@ type ValidationError struct {
 }
 
 func (b *Binder) ReadBody(c *HttpContext, result interface{}) error {
-
-	if err := readBody(c, result); err != nil {
+	if err := b.readBody(c, result); err != nil {
 		return exception.NewMalformedRequestProblem()
 	}
 
 	if vErr := b.validator.Validate(result); vErr != nil {
 		customErrors := b.validator.MapValidationProblems(vErr)
-
 		return exception.NewValidationProblem(customErrors)
 	}
 
 	return nil
 }
 
-func readBody(c *HttpContext, toBind interface{}) error {
-
-	if c.Request().ContentLength == 0 {
+func (b *Binder) readBody(c *HttpContext, toBind interface{}) error {
+	if c.Request().ContentLength == 0 || c.Request().Body == nil {
 		return nil
 	}
 
-	read, err := io.ReadAll(c.Request().Body)
-	if err != nil {
-		return err
+	decoder := json.NewDecoder(c.Request().Body)
+	if b.options.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
 	}
 
-	err = json.Unmarshal(read, toBind)
+	err := decoder.Decode(toBind)
+	if err == io.EOF {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
+	if b.options.DisallowTrailingData {
+		if _, err := decoder.Token(); err != io.EOF {
+			return errTrailingData
+		}
+	}
+
 	return nil
 }
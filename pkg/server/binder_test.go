@@ -0,0 +1,71 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/pkg/exception"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type bindingRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+func TestBinder_ReadBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     BindingOptions
+		body        string
+		expectedErr error
+	}{
+		{
+			name:    "Should bind given valid body",
+			options: BindingOptions{},
+			body:    `{"title":"Supermarket"}`,
+		},
+		{
+			name:    "Should bind given unknown fields when they are allowed",
+			options: BindingOptions{},
+			body:    `{"title":"Supermarket","unknown":true}`,
+		},
+		{
+			name:        "Should return malformed request given unknown fields when they are not allowed",
+			options:     BindingOptions{DisallowUnknownFields: true},
+			body:        `{"title":"Supermarket","unknown":true}`,
+			expectedErr: exception.NewMalformedRequestProblem(),
+		},
+		{
+			name:    "Should bind given trailing data when it is allowed",
+			options: BindingOptions{},
+			body:    `{"title":"Supermarket"}{"title":"Other"}`,
+		},
+		{
+			name:        "Should return malformed request given trailing data when it is not allowed",
+			options:     BindingOptions{DisallowTrailingData: true},
+			body:        `{"title":"Supermarket"}{"title":"Other"}`,
+			expectedErr: exception.NewMalformedRequestProblem(),
+		},
+		{
+			name:    "Should bind given trailing whitespace when trailing data is not allowed",
+			options: BindingOptions{DisallowTrailingData: true},
+			body:    "{\"title\":\"Supermarket\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			ctx := &HttpContext{request: request}
+
+			var result bindingRequest
+			err := NewBinder(tt.options).ReadBody(ctx, &result)
+
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Supermarket", result.Title)
+		})
+	}
+}
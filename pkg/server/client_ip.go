@@ -0,0 +1,128 @@
+package server
+
+import (
+	"github.com/yurikilian/bills/pkg/ipfilter"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPResolver finds the real client address, trusting a single proxy
+// header only when the connection comes from a trusted proxy.
+type ClientIPResolver struct {
+	header  string
+	trusted []netip.Prefix
+}
+
+// NewClientIPResolver reads header, either Forwarded or a comma separated list
+// such as X-Forwarded-For. Any other proxy header is ignored, so clients can't
+// pick the one the proxies leave untouched.
+func NewClientIPResolver(header string, trustedProxies []string) *ClientIPResolver {
+	resolver := &ClientIPResolver{header: http.CanonicalHeaderKey(header)}
+	for _, cidr := range trustedProxies {
+		if prefix, err := ipfilter.ParsePrefix(cidr); err == nil {
+			resolver.trusted = append(resolver.trusted, prefix)
+		}
+	}
+	return resolver
+}
+
+// unixPeer stands for the peers of unix socket listeners, which have no
+// address but can only be local processes.
+var unixPeer = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+
+// Resolve returns the client address. Requests accepted on a unix socket come
+// from unixPeer, so loopback rules and trusted proxies apply to them.
+func (r *ClientIPResolver) Resolve(request *http.Request) netip.Addr {
+	remote := remoteAddr(request.RemoteAddr)
+	if !remote.IsValid() && isUnixSocket(request) {
+		remote = unixPeer
+	}
+	if !r.isTrusted(remote) {
+		return remote
+	}
+
+	chain := r.forwardedChain(request.Header)
+	for i := len(chain) - 1; i >= 0; i-- {
+		// An unknown or obfuscated hop hides everything on its left, so the
+		// walk stops there instead of skipping to an address it can't vouch for.
+		if !r.isTrusted(chain[i]) {
+			return chain[i]
+		}
+	}
+
+	if len(chain) > 0 {
+		return chain[0]
+	}
+	return remote
+}
+
+func (r *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedChain returns the addresses appended by proxies to the trusted
+// header, from the client to the closest proxy. Unknown and obfuscated hops
+// are kept as invalid addresses.
+func (r *ClientIPResolver) forwardedChain(header http.Header) []netip.Addr {
+	chain := make([]netip.Addr, 0)
+	if len(r.header) == 0 {
+		return chain
+	}
+
+	for _, value := range header.Values(r.header) {
+		for _, element := range strings.Split(value, ",") {
+			if r.header == "Forwarded" {
+				element = forwardedFor(element)
+			}
+			chain = append(chain, parseForwardedAddr(element))
+		}
+	}
+	return chain
+}
+
+// forwardedFor returns the for parameter of a Forwarded element.
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(key, "for") {
+			return value
+		}
+	}
+	return ""
+}
+
+func parseForwardedAddr(value string) netip.Addr {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func isUnixSocket(request *http.Request) bool {
+	local, ok := request.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && local.Network() == "unix"
+}
+
+func remoteAddr(value string) netip.Addr {
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	return parseForwardedAddr(value)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver_Resolve(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.168.1.1"}
+	forwardedFor := NewClientIPResolver("X-Forwarded-For", trusted)
+	forwarded := NewClientIPResolver("Forwarded", trusted)
+
+	tests := []struct {
+		name       string
+		resolver   *ClientIPResolver
+		remoteAddr string
+		unix       bool
+		headers    map[string]string
+		expected   string
+	}{
+		{name: "Should return remote address given no proxy headers", resolver: forwardedFor, remoteAddr: "203.0.113.7:5000", expected: "203.0.113.7"},
+		{name: "Should ignore X-Forwarded-For given untrusted remote address", resolver: forwardedFor, remoteAddr: "203.0.113.7:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, expected: "203.0.113.7"},
+		{name: "Should return first untrusted hop given trusted proxies", resolver: forwardedFor, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.9, 192.168.1.1"}, expected: "203.0.113.9"},
+		{name: "Should return leftmost hop given every hop is trusted", resolver: forwardedFor, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"X-Forwarded-For": "10.0.0.5, 10.0.0.4"}, expected: "10.0.0.5"},
+		{name: "Should ignore Forwarded given X-Forwarded-For is the trusted header", resolver: forwardedFor, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"Forwarded": "for=198.51.100.1", "X-Forwarded-For": "203.0.113.9"}, expected: "203.0.113.9"},
+		{name: "Should stop at unknown hop given X-Forwarded-For", resolver: forwardedFor, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1, unknown, 10.0.0.5"}, expected: "invalid IP"},
+		{name: "Should return first untrusted hop given Forwarded", resolver: forwarded, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"Forwarded": `for=198.51.100.1;proto=https, for="[2001:db8::1]:4711"`}, expected: "2001:db8::1"},
+		{name: "Should ignore X-Forwarded-For given Forwarded is the trusted header", resolver: forwarded, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"Forwarded": "for=198.51.100.1", "X-Forwarded-For": "203.0.113.9"}, expected: "198.51.100.1"},
+		{name: "Should stop at obfuscated hop given Forwarded", resolver: forwarded, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"Forwarded": "for=198.51.100.1, for=_hidden, for=10.0.0.5"}, expected: "invalid IP"},
+		{name: "Should stop at unknown hop given Forwarded", resolver: forwarded, remoteAddr: "10.0.0.2:5000", headers: map[string]string{"Forwarded": "for=198.51.100.1, for=unknown"}, expected: "invalid IP"},
+		{name: "Should return loopback given unix socket peer", resolver: forwardedFor, remoteAddr: "@", unix: true, expected: "127.0.0.1"},
+		{name: "Should return invalid address given unparsable tcp peer", resolver: forwardedFor, remoteAddr: "@", expected: "invalid IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.unix {
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/run/bills.sock", Net: "unix"}))
+			}
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			assert.Equal(t, tt.expected, tt.resolver.Resolve(req).String())
+		})
+	}
+}
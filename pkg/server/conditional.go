@@ -0,0 +1,116 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/yurikilian/bills/pkg/exception"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WeakETag derives a weak validator from a serialized representation.
+func WeakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// VersionETag builds a strong validator from a resource version, such as a
+// version column, which changes on every update.
+func VersionETag(version string) string {
+	return `"` + version + `"`
+}
+
+func bodyAllowedForStatus(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// NotModified reports whether the conditional headers of request match the
+// validators set in header, so a 304 can be sent instead of the body.
+func NotModified(request *http.Request, header http.Header) bool {
+	return isNotModified(request, header)
+}
+
+// isNotModified evaluates If-None-Match and, when absent, If-Modified-Since
+// against the validators already set on the response.
+func isNotModified(request *http.Request, header http.Header) bool {
+	if ifNoneMatch := request.Header.Get("If-None-Match"); len(ifNoneMatch) > 0 {
+		return etagMatches(ifNoneMatch, header.Get("ETag"), false)
+	}
+
+	ifModifiedSince := request.Header.Get("If-Modified-Since")
+	lastModified := header.Get("Last-Modified")
+	if len(ifModifiedSince) == 0 || len(lastModified) == 0 {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// checkPreconditions evaluates If-Match and, when absent, If-Unmodified-Since
+// against the current state of the resource.
+func checkPreconditions(request *http.Request, etag string, lastModified time.Time) error {
+	if ifMatch := request.Header.Get("If-Match"); len(ifMatch) > 0 {
+		if !etagMatches(ifMatch, etag, true) {
+			return exception.NewPreconditionFailedProblem("The resource was modified, If-Match does not match its current ETag")
+		}
+		return nil
+	}
+
+	ifUnmodifiedSince := request.Header.Get("If-Unmodified-Since")
+	if len(ifUnmodifiedSince) == 0 || lastModified.IsZero() {
+		return nil
+	}
+
+	since, err := http.ParseTime(ifUnmodifiedSince)
+	if err != nil {
+		return nil
+	}
+	if lastModified.Truncate(time.Second).After(since) {
+		return exception.NewPreconditionFailedProblem("The resource was modified after If-Unmodified-Since")
+	}
+	return nil
+}
+
+// etagMatches compares etag with a list of entity tags. Strong comparison, used
+// by If-Match, never matches weak tags.
+func etagMatches(list string, etag string, strong bool) bool {
+	if len(etag) == 0 {
+		return false
+	}
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strong {
+			if !isWeak(candidate) && !isWeak(etag) && candidate == etag {
+				return true
+			}
+			continue
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isWeak(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHttpContext_WriteResponse_Conditional(t *testing.T) {
+	lastModified := time.Date(2023, time.January, 10, 0, 0, 0, 0, time.UTC)
+
+	server := NewRestServer(NewRestServerOptions(":0", logger.NewProvider().ProvideLog()))
+	server.Router(NewRestRouter().
+		Get("/computed", func(ctx IHttpContext) error {
+			return ctx.WriteResponse(http.StatusOK, "transaction")
+		}).
+		Get("/versioned", func(ctx IHttpContext) error {
+			ctx.Writer().Header().Set("ETag", VersionETag("7"))
+			ctx.Writer().Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+			return ctx.WriteResponse(http.StatusOK, "transaction")
+		}).
+		POST("/versioned", func(ctx IHttpContext) error {
+			if err := ctx.CheckPreconditions(VersionETag("7"), lastModified); err != nil {
+				return err
+			}
+			return ctx.WriteResponse(http.StatusNoContent, nil)
+		}))
+
+	computedETag := WeakETag([]byte("\"transaction\"\n"))
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedETag       string
+	}{
+		{name: "Should compute weak etag given no etag from handler", method: http.MethodGet, path: "/computed", expectedStatusCode: http.StatusOK, expectedETag: computedETag},
+		{name: "Should return not modified given matching If-None-Match", method: http.MethodGet, path: "/computed", headers: map[string]string{"If-None-Match": computedETag}, expectedStatusCode: http.StatusNotModified, expectedETag: computedETag},
+		{name: "Should return ok given stale If-None-Match", method: http.MethodGet, path: "/computed", headers: map[string]string{"If-None-Match": `W/"stale"`}, expectedStatusCode: http.StatusOK, expectedETag: computedETag},
+		{name: "Should keep handler etag given version etag", method: http.MethodGet, path: "/versioned", expectedStatusCode: http.StatusOK, expectedETag: `"7"`},
+		{name: "Should return not modified given If-Modified-Since after last modification", method: http.MethodGet, path: "/versioned", headers: map[string]string{"If-Modified-Since": lastModified.Add(time.Hour).Format(http.TimeFormat)}, expectedStatusCode: http.StatusNotModified, expectedETag: `"7"`},
+		{name: "Should return ok given If-Modified-Since before last modification", method: http.MethodGet, path: "/versioned", headers: map[string]string{"If-Modified-Since": lastModified.Add(-time.Hour).Format(http.TimeFormat)}, expectedStatusCode: http.StatusOK, expectedETag: `"7"`},
+		{name: "Should process update given matching If-Match", method: http.MethodPost, path: "/versioned", headers: map[string]string{"If-Match": `"7"`}, expectedStatusCode: http.StatusNoContent},
+		{name: "Should return precondition failed given stale If-Match", method: http.MethodPost, path: "/versioned", headers: map[string]string{"If-Match": `"6"`}, expectedStatusCode: http.StatusPreconditionFailed},
+		{name: "Should return precondition failed given weak If-Match", method: http.MethodPost, path: "/versioned", headers: map[string]string{"If-Match": `W/"7"`}, expectedStatusCode: http.StatusPreconditionFailed},
+		{name: "Should return precondition failed given If-Unmodified-Since before last modification", method: http.MethodPost, path: "/versioned", headers: map[string]string{"If-Unmodified-Since": lastModified.Add(-time.Hour).Format(http.TimeFormat)}, expectedStatusCode: http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			server.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			assert.Equal(t, tt.expectedETag, rec.Header().Get("ETag"))
+			if tt.expectedStatusCode == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
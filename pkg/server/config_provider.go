@@ -1,15 +1,26 @@
 package server
 
 import (
+	"fmt"
+	"net/url"
 	"os"
 	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 )
 
 type applicationConfig struct {
-	DBConnectionString string `mapstructure:"DB_CONNECTION_STRING" validate:"required"`
+	DBConnectionString string `mapstructure:"DB_CONNECTION_STRING" validate:"required" redact:"true"`
+
+	ServerReadHeaderTimeout time.Duration `mapstructure:"SERVER_READ_HEADER_TIMEOUT" validate:"gte=0"`
+	ServerReadTimeout       time.Duration `mapstructure:"SERVER_READ_TIMEOUT" validate:"gte=0"`
+	ServerWriteTimeout      time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT" validate:"gte=0"`
+	ServerIdleTimeout       time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT" validate:"gte=0"`
+	ServerMaxHeaderBytes    int           `mapstructure:"SERVER_MAX_HEADER_BYTES" validate:"gte=0"`
+	ServerDisableKeepAlives bool          `mapstructure:"SERVER_DISABLE_KEEP_ALIVES"`
 }
 
 type ConfigurationProvider struct {
@@ -35,7 +46,9 @@ func (cfg *ConfigurationProvider) loadConfig() {
 		if envKey, ok := field.Tag.Lookup("mapstructure"); ok {
 			value := os.Getenv(envKey)
 			if len(value) > 0 {
-				st.Field(i).SetString(value)
+				if err := setField(st.Field(i), value); err != nil {
+					panic(fmt.Errorf("invalid %v: %w", envKey, err))
+				}
 			}
 
 		}
@@ -49,6 +62,96 @@ func (cfg *ConfigurationProvider) loadConfig() {
 	cfg.config = &config
 }
 
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %v", field.Type())
+	}
+	return nil
+}
+
+// GetServerLimits returns the safe defaults overridden by any configured value.
+func (cfg *ConfigurationProvider) GetServerLimits() LimitOptions {
+	limits := NewLimitOptions()
+	if cfg.config.ServerReadHeaderTimeout > 0 {
+		limits.ReadHeaderTimeout = cfg.config.ServerReadHeaderTimeout
+	}
+	if cfg.config.ServerReadTimeout > 0 {
+		limits.ReadTimeout = cfg.config.ServerReadTimeout
+	}
+	if cfg.config.ServerWriteTimeout > 0 {
+		limits.WriteTimeout = cfg.config.ServerWriteTimeout
+	}
+	if cfg.config.ServerIdleTimeout > 0 {
+		limits.IdleTimeout = cfg.config.ServerIdleTimeout
+	}
+	if cfg.config.ServerMaxHeaderBytes > 0 {
+		limits.MaxHeaderBytes = cfg.config.ServerMaxHeaderBytes
+	}
+	limits.DisableKeepAlives = cfg.config.ServerDisableKeepAlives
+	return limits
+}
+
+// Effective returns the loaded configuration keyed by variable name, with the
+// values of fields tagged redact replaced so they can be displayed.
+func (cfg *ConfigurationProvider) Effective() map[string]interface{} {
+	effective := map[string]interface{}{}
+
+	st := reflect.ValueOf(cfg.config).Elem()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Type().Field(i)
+		key, ok := field.Tag.Lookup("mapstructure")
+		if !ok {
+			continue
+		}
+
+		value := st.Field(i).Interface()
+		if field.Tag.Get("redact") == "true" {
+			value = redact(fmt.Sprint(value))
+		} else if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		effective[key] = value
+	}
+	return effective
+}
+
+// redact keeps the non secret parts of connection URLs to ease debugging.
+func redact(value string) string {
+	if len(value) == 0 {
+		return value
+	}
+
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), "REDACTED")
+		}
+		u.RawQuery = ""
+		return u.String()
+	}
+	return "REDACTED"
+}
+
 func (cfg *ConfigurationProvider) GetDBConnectionString() *string {
 	return &cfg.config.DBConnectionString
 
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/net/http2"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+)
+
+// ErrInvalidOptions wraps the validation failures of the server options.
+var ErrInvalidOptions = errors.New("invalid server options")
+
+// Hook is run at a stage of the server lifecycle. Hooks of a stage run in
+// registration order while starting and in reverse order while stopping.
+type Hook func(ctx context.Context) error
+
+type optionsError struct {
+	err error
+}
+
+func (e *optionsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *optionsError) Unwrap() error {
+	return e.err
+}
+
+func (e *optionsError) Is(target error) bool {
+	return target == ErrInvalidOptions
+}
+
+// OnStart registers a hook run before the listeners are bound. An error
+// aborts the start.
+func (srv *RestServer) OnStart(hook Hook) *RestServer {
+	srv.startHooks = append(srv.startHooks, hook)
+	return srv
+}
+
+// OnReady registers a hook run once the server accepts connections. An error
+// shuts the server down.
+func (srv *RestServer) OnReady(hook Hook) *RestServer {
+	srv.readyHooks = append(srv.readyHooks, hook)
+	return srv
+}
+
+// OnStop registers a hook run as soon as a shutdown starts, before in-flight
+// requests are drained, e.g. to deregister from service discovery. Stop and
+// shutdown hooks also run when the server fails to listen or to serve after
+// the start hooks succeeded.
+func (srv *RestServer) OnStop(hook Hook) *RestServer {
+	srv.stopHooks = append(srv.stopHooks, hook)
+	return srv
+}
+
+// Ready is closed once every listener is bound and serving.
+func (srv *RestServer) Ready() <-chan struct{} {
+	return srv.readyCh
+}
+
+// Run serves until ctx is cancelled or a shutdown signal is received, then
+// shuts down gracefully. It returns nil after a graceful stop and an error
+// when the server could not start or stopped serving unexpectedly.
+func (srv *RestServer) Run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err := Validator.Validate(srv.options)
+	if err != nil {
+		return &optionsError{err: err}
+	}
+
+	if srv.options.TLS != nil {
+		srv.server.TLSConfig, err = srv.options.TLS.Config()
+		if err != nil {
+			return &optionsError{err: err}
+		}
+	}
+
+	for _, hook := range srv.startHooks {
+		if err = hook(ctx); err != nil {
+			return fmt.Errorf("start hook failed: %w", err)
+		}
+	}
+
+	srv.mux.Handle("/", srv)
+	srv.options.Limits.apply(srv.server)
+
+	listeners, err := srv.listen(ctx)
+	if err != nil {
+		return srv.abort(err)
+	}
+
+	srv.server.Handler = srv.mux
+	if hasH2C(listeners) {
+		h2s := &http2.Server{IdleTimeout: srv.options.Limits.IdleTimeout}
+		if err = http2.ConfigureServer(srv.server, h2s); err != nil {
+			closeListeners(srv.inheritable)
+			return srv.abort(err)
+		}
+		srv.server.Handler = listenerHandler(srv.mux, h2s)
+		srv.server.BaseContext = srv.baseContext
+	}
+
+	signalCtx, stop := signal.NotifyContext(ctx, srv.shutdownSignals()...)
+	defer stop()
+
+	upgradeSignals := make(chan os.Signal, 1)
+	if srv.options.Upgrade != nil && len(srv.options.Upgrade.Signals) > 0 {
+		signal.Notify(upgradeSignals, srv.options.Upgrade.Signals...)
+		defer signal.Stop(upgradeSignals)
+	}
+
+	serveErr := make(chan error, len(listeners)+len(srv.attachments))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			serveErr <- srv.server.Serve(listener)
+		}(listener)
+	}
+	srv.serveAttached(serveErr)
+	srv.ready.Store(true)
+	srv.readyOnce.Do(func() { close(srv.readyCh) })
+
+	for _, hook := range srv.readyHooks {
+		if err = hook(ctx); err != nil {
+			return errors.Join(fmt.Errorf("ready hook failed: %w", err), srv.Shutdown(context.Background()))
+		}
+	}
+
+	if err = notifyUpgradeParent(); err != nil {
+		srv.options.Log.Error(ctx, fmt.Sprintf("Could not notify the upgrading process: %v", err))
+	}
+
+	for {
+		select {
+		case err = <-serveErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				// Shutdown was called elsewhere, wait until it completes.
+				return srv.Shutdown(context.Background())
+			}
+			// Listeners live and die together.
+			srv.ready.Store(false)
+			_ = srv.server.Close()
+			closed, cancel := context.WithCancel(context.Background())
+			cancel()
+			srv.shutdownAttached(closed)
+			return srv.abort(err)
+		case <-upgradeSignals:
+			if err = srv.Upgrade(ctx); err != nil {
+				srv.options.Log.Error(ctx, fmt.Sprintf("Binary upgrade failed, still serving: %v", err))
+				continue
+			}
+			srv.options.Log.Info(ctx, "Binary upgrade handed over, draining in-flight requests")
+		case <-signalCtx.Done():
+			srv.options.Log.Info(ctx, "Shutdown requested, draining in-flight requests")
+		}
+
+		return srv.Shutdown(context.Background())
+	}
+}
+
+// Serve handles requests on listener without the lifecycle of Run, for a
+// server attached to another one with AttachListener. Shutdown stops it.
+func (srv *RestServer) Serve(listener net.Listener) error {
+	if err := Validator.Validate(srv.options); err != nil {
+		return &optionsError{err: err}
+	}
+
+	srv.mux.Handle("/", srv)
+	srv.options.Limits.apply(srv.server)
+	srv.server.Handler = srv.mux
+
+	srv.ready.Store(true)
+	srv.readyOnce.Do(func() { close(srv.readyCh) })
+	return srv.server.Serve(listener)
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/internal/logger"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRestServer_Run(t *testing.T) {
+	stages := make([]string, 0)
+	record := func(stage string) Hook {
+		return func(ctx context.Context) error {
+			stages = append(stages, stage)
+			return nil
+		}
+	}
+
+	server := NewRestServer(NewRestServerOptions("127.0.0.1:0", logger.NewProvider().ProvideLog())).
+		Router(NewRestRouter()).
+		OnStart(record("start")).
+		OnReady(record("ready")).
+		OnStop(record("stop")).
+		OnShutdown(record("shutdown"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Run(ctx)
+	}()
+
+	select {
+	case <-server.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("server was not ready")
+	}
+
+	conn, err := net.Dial("tcp", server.Addrs()[0].String())
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	cancel()
+	select {
+	case err = <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop after the context was cancelled")
+	}
+	assert.Equal(t, []string{"start", "ready", "stop", "shutdown"}, stages)
+}
+
+func TestRestServer_Run_Errors(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer occupied.Close()
+
+	log := logger.NewProvider().ProvideLog()
+	failing := func(ctx context.Context) error { return errors.New("migration failed") }
+
+	tests := []struct {
+		name      string
+		server    *RestServer
+		wantErr   string
+		wantIsErr error
+	}{
+		{
+			name:      "Should report invalid options",
+			server:    NewRestServer(&Options{BindAddress: ":0"}),
+			wantErr:   "Key: 'Options.Log' Error:Field validation for 'Log' failed on the 'required' tag",
+			wantIsErr: ErrInvalidOptions,
+		},
+		{
+			name:    "Should report listen failures",
+			server:  NewRestServer(NewRestServerOptions(occupied.Addr().String(), log)),
+			wantErr: "address already in use",
+		},
+		{
+			name:    "Should abort when a start hook fails",
+			server:  NewRestServer(NewRestServerOptions("127.0.0.1:0", log)).OnStart(failing),
+			wantErr: "start hook failed: migration failed",
+		},
+		{
+			name:    "Should shut down when a ready hook fails",
+			server:  NewRestServer(NewRestServerOptions("127.0.0.1:0", log)).OnReady(failing),
+			wantErr: "ready hook failed: migration failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.server.Router(NewRestRouter()).Run(context.Background())
+
+			assert.ErrorContains(t, err, tt.wantErr)
+			if tt.wantIsErr != nil {
+				assert.ErrorIs(t, err, tt.wantIsErr)
+			}
+			assert.False(t, tt.server.IsReady())
+		})
+	}
+}
+
+func TestRestServer_Run_StopsWhenListenFails(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer occupied.Close()
+
+	stages := make([]string, 0)
+	err = NewRestServer(NewRestServerOptions(occupied.Addr().String(), logger.NewProvider().ProvideLog())).
+		Router(NewRestRouter()).
+		OnStart(func(ctx context.Context) error {
+			stages = append(stages, "start")
+			return nil
+		}).
+		OnStop(func(ctx context.Context) error {
+			stages = append(stages, "stop")
+			return errors.New("deregistration failed")
+		}).
+		OnShutdown(func(ctx context.Context) error {
+			stages = append(stages, "shutdown")
+			return nil
+		}).
+		Run(context.Background())
+
+	assert.ErrorContains(t, err, "address already in use")
+	assert.ErrorContains(t, err, "deregistration failed")
+	assert.Equal(t, []string{"start", "stop", "shutdown"}, stages)
+}
+
+func TestRestServer_Run_StopsWhenServeFails(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	stages := make([]string, 0)
+	record := func(stage string) Hook {
+		return func(ctx context.Context) error {
+			stages = append(stages, stage)
+			return nil
+		}
+	}
+
+	server := NewRestServer(NewRestServerOptions("127.0.0.1:0", logger.NewProvider().ProvideLog())).
+		Router(NewRestRouter()).
+		OnStart(record("start")).
+		OnStop(record("stop")).
+		OnShutdown(record("shutdown")).
+		AttachListener(ListenerOptions{Listener: listener}, func(net.Listener) error {
+			return errors.New("accept failed")
+		}, func(ctx context.Context) error { return listener.Close() })
+
+	err = server.Run(context.Background())
+
+	assert.EqualError(t, err, "accept failed")
+	assert.Equal(t, []string{"start", "stop", "shutdown"}, stages)
+	assert.NoError(t, server.Shutdown(context.Background()))
+	assert.False(t, server.IsReady())
+}
+
+func TestRestServer_AttachListener(t *testing.T) {
+	log := logger.NewProvider().ProvideLog()
+	attached := NewRestServer(NewRestServerOptions("127.0.0.1:0", log)).
+		Router(NewRestRouter().Get("/routes", func(ctx IHttpContext) error {
+			return ctx.WriteResponse(http.StatusOK, "attached")
+		}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+
+	server := NewRestServer(NewRestServerOptions("127.0.0.1:0", log)).
+		Router(NewRestRouter()).
+		AttachListener(ListenerOptions{Listener: listener}, attached.Serve, attached.Shutdown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Run(ctx)
+	}()
+	<-server.Ready()
+
+	res, err := http.Get("http://" + address + "/routes")
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, server.Addrs(), 1)
+
+	cancel()
+	select {
+	case err = <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop after the context was cancelled")
+	}
+	assert.False(t, attached.IsReady())
+
+	_, err = net.Dial("tcp", address)
+	assert.Error(t, err, "the attached listener should be closed")
+}
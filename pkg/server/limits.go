@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// LimitOptions bounds how long and how much a single connection may consume.
+// Zero durations disable the corresponding timeout.
+type LimitOptions struct {
+	// ReadHeaderTimeout protects against slowloris clients trickling headers.
+	ReadHeaderTimeout time.Duration `validate:"gte=0"`
+	ReadTimeout       time.Duration `validate:"gte=0"`
+	// WriteTimeout must exceed the slowest route, including middleware timeouts.
+	WriteTimeout      time.Duration `validate:"gte=0"`
+	IdleTimeout       time.Duration `validate:"gte=0"`
+	MaxHeaderBytes    int           `validate:"gte=0,lte=16777216"`
+	DisableKeepAlives bool
+}
+
+func NewLimitOptions() LimitOptions {
+	return LimitOptions{
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+}
+
+func (l LimitOptions) apply(server *http.Server) {
+	server.ReadHeaderTimeout = l.ReadHeaderTimeout
+	server.ReadTimeout = l.ReadTimeout
+	server.WriteTimeout = l.WriteTimeout
+	server.IdleTimeout = l.IdleTimeout
+	server.MaxHeaderBytes = l.MaxHeaderBytes
+	server.SetKeepAlivesEnabled(!l.DisableKeepAlives)
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+)
+
+type h2cListenerKey struct{}
+
+// ListenerOptions describes one socket the server accepts connections on.
+// Every listener shares the router, the middlewares and the shutdown.
+type ListenerOptions struct {
+	// Network is "tcp" or "unix". Ignored when Listener is set.
+	Network string `validate:"required_without=Listener,omitempty,oneof=tcp tcp4 tcp6 unix"`
+	Address string `validate:"required_without=Listener"`
+	// Listener is an already bound socket, e.g. inherited from systemd.
+	Listener net.Listener
+	// H2C serves cleartext HTTP/2 with prior knowledge or upgrade. H2C
+	// listeners are never wrapped in TLS.
+	H2C bool
+}
+
+func (l ListenerOptions) listen() (net.Listener, error) {
+	if l.Listener != nil {
+		return l.Listener, nil
+	}
+
+	if l.Network == "unix" {
+		if err := removeStaleSocket(l.Address); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen(l.Network, l.Address)
+}
+
+func (l ListenerOptions) String() string {
+	if l.Listener != nil {
+		return fmt.Sprintf("%v:%v", l.Listener.Addr().Network(), l.Listener.Addr())
+	}
+	return fmt.Sprintf("%v:%v", l.Network, l.Address)
+}
+
+// removeStaleSocket deletes a socket file left behind by a previous process,
+// refusing to touch anything that is not a socket.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%v exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+// listenerOptions returns the BindAddress listener followed by the extra ones.
+func (srv *RestServer) listenerOptions() []ListenerOptions {
+	listeners := make([]ListenerOptions, 0, len(srv.options.Listeners)+1)
+	if len(srv.options.BindAddress) > 0 {
+		listeners = append(listeners, ListenerOptions{Network: "tcp", Address: srv.options.BindAddress})
+	}
+	return append(listeners, srv.options.Listeners...)
+}
+
+// ServeFunc serves an attached listener until the attached server is shut
+// down.
+type ServeFunc func(listener net.Listener) error
+
+type attachment struct {
+	listener ListenerOptions
+	serve    ServeFunc
+	shutdown Hook
+}
+
+// AttachListener binds listener together with the server listeners, so it is
+// handed over on binary upgrades, and serves it with serve once every listener
+// is bound. shutdown drains it within the server grace period. The attached
+// server lives and dies with this one, e.g. a gRPC or an admin server.
+func (srv *RestServer) AttachListener(listener ListenerOptions, serve ServeFunc, shutdown Hook) *RestServer {
+	srv.attachments = append(srv.attachments, attachment{listener: listener, serve: serve, shutdown: shutdown})
+	return srv
+}
+
+// listen binds every configured listener, then the attached ones, closing the
+// already bound ones when one of them fails. Only the server listeners are
+// returned, the attached ones are kept in srv.attached.
+func (srv *RestServer) listen(ctx context.Context) ([]net.Listener, error) {
+	options := srv.listenerOptions()
+	for _, attachment := range srv.attachments {
+		options = append(options, attachment.listener)
+	}
+	served := len(options) - len(srv.attachments)
+
+	listeners := make([]net.Listener, 0, len(options))
+	inheritable := make([]net.Listener, 0, len(options))
+
+	inherited, err := inheritedListeners()
+	if err != nil {
+		return nil, err
+	}
+	if len(inherited) > 0 && len(inherited) != len(options) {
+		closeListeners(inherited)
+		return nil, fmt.Errorf("inherited %d listeners but %d are configured", len(inherited), len(options))
+	}
+
+	for i, option := range options {
+		if len(inherited) > 0 {
+			option.Listener = inherited[i]
+		}
+		srv.options.Log.Info(ctx, fmt.Sprintf("Starting server on %v address", option))
+
+		listener, err := option.listen()
+		if err != nil {
+			closeListeners(inheritable)
+			return nil, err
+		}
+		inheritable = append(inheritable, listener)
+		if i >= served {
+			continue
+		}
+
+		if option.H2C {
+			listener = &h2cListener{Listener: listener}
+		} else if srv.server.TLSConfig != nil {
+			listener = tls.NewListener(listener, srv.server.TLSConfig)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	srv.listenersMu.Lock()
+	srv.listeners = listeners
+	srv.attached = inheritable[served:]
+	srv.inheritable = inheritable
+	srv.listenersMu.Unlock()
+	return listeners, nil
+}
+
+// serveAttached serves every attached listener, reporting a graceful stop as
+// http.ErrServerClosed like the server listeners do.
+func (srv *RestServer) serveAttached(serveErr chan<- error) {
+	for i, attachment := range srv.attachments {
+		go func(serve ServeFunc, listener net.Listener) {
+			err := serve(listener)
+			if err == nil {
+				err = http.ErrServerClosed
+			}
+			serveErr <- err
+		}(attachment.serve, srv.attached[i])
+	}
+}
+
+// shutdownAttached drains the attached servers in reverse attach order.
+func (srv *RestServer) shutdownAttached(ctx context.Context) []error {
+	var errs []error
+	for i := len(srv.attachments) - 1; i >= 0; i-- {
+		if err := srv.attachments[i].shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+}
+
+// h2cListener marks the connections accepted on it so the handler can speak
+// cleartext HTTP/2 on them.
+type h2cListener struct {
+	net.Listener
+}
+
+func (srv *RestServer) baseContext(listener net.Listener) context.Context {
+	_, isH2C := listener.(*h2cListener)
+	return context.WithValue(context.Background(), h2cListenerKey{}, isH2C)
+}
+
+// listenerHandler upgrades to h2c only for requests received on h2c listeners.
+func listenerHandler(handler http.Handler, h2s *http2.Server) http.Handler {
+	h2cHandler := h2c.NewHandler(handler, h2s)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isH2C, _ := r.Context().Value(h2cListenerKey{}).(bool); isH2C {
+			h2cHandler.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func hasH2C(listeners []net.Listener) bool {
+	for _, listener := range listeners {
+		if _, ok := listener.(*h2cListener); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Addrs returns the addresses the server is listening on once it is ready.
+func (srv *RestServer) Addrs() []net.Addr {
+	srv.listenersMu.Lock()
+	defer srv.listenersMu.Unlock()
+
+	addrs := make([]net.Addr, 0, len(srv.listeners))
+	for _, listener := range srv.listeners {
+		addrs = append(addrs, listener.Addr())
+	}
+	return addrs
+}
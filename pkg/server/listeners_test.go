@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/internal/logger"
+	"golang.org/x/net/http2"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRestServer_Start_MultipleListeners(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bills.sock")
+	provided, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := NewRestServer(NewRestServerOptions("127.0.0.1:0", logger.NewProvider().ProvideLog()).
+		WithListener(ListenerOptions{Network: "unix", Address: socket}).
+		WithListener(ListenerOptions{Listener: provided, H2C: true})).
+		Router(NewRestRouter().Get("/", func(ctx IHttpContext) error {
+			return ctx.WriteResponse(http.StatusOK, ctx.Request().Proto)
+		}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		server.Start(ctx)
+	}()
+	require.Eventually(t, server.IsReady, time.Second, time.Millisecond)
+
+	addrs := server.Addrs()
+	require.Len(t, addrs, 3)
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		client    *http.Client
+		url       string
+		wantProto string
+	}{
+		{
+			name:      "Should serve HTTP/1.1 on the bind address",
+			client:    http.DefaultClient,
+			url:       "http://" + addrs[0].String(),
+			wantProto: "HTTP/1.1",
+		},
+		{
+			name:      "Should serve on the unix socket",
+			client:    unixClient,
+			url:       "http://unix/",
+			wantProto: "HTTP/1.1",
+		},
+		{
+			name:      "Should serve h2c with prior knowledge on the provided listener",
+			client:    h2cClient,
+			url:       "http://" + addrs[2].String(),
+			wantProto: "HTTP/2.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.client.Get(tt.url)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, strconv.Quote(tt.wantProto)+"\n", string(body))
+		})
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop after the context was cancelled")
+	}
+
+	for _, addr := range addrs {
+		_, err := net.DialTimeout(addr.Network(), addr.String(), 100*time.Millisecond)
+		assert.Error(t, err, "%v should be closed", addr)
+	}
+}
+
+func TestRestServer_Start_RefusesToReplaceFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bills.sock")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0600))
+
+	options := NewRestServerOptions("", logger.NewProvider().ProvideLog()).
+		WithListener(ListenerOptions{Network: "unix", Address: path})
+	problem, ok := NewRestServer(options).Router(NewRestRouter()).Start(context.Background())
+
+	assert.True(t, ok)
+	assert.Equal(t, path+" exists and is not a socket", problem.Message)
+}
+
+func TestSystemdListeners_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := SystemdListeners()
+
+	assert.NoError(t, err)
+	assert.Empty(t, listeners)
+}
@@ -1,12 +1,89 @@
 package server
 
-import "github.com/yurikilian/bills/pkg/logger"
+import (
+	"github.com/yurikilian/bills/pkg/logger"
+	"os"
+	"syscall"
+	"time"
+)
+
+const defaultShutdownGracePeriod = 30 * time.Second
 
 type Options struct {
-	BindAddress string        `validate:"required"`
+	BindAddress string        `validate:"required_without=Listeners"`
 	Log         logger.Logger `validate:"required"`
+	Binding     BindingOptions
+	Limits      LimitOptions
+	// Listeners are served in addition to BindAddress.
+	Listeners []ListenerOptions `validate:"dive"`
+	// TrustedProxies lists the CIDRs allowed to set TrustedProxyHeader.
+	TrustedProxies []string `validate:"dive,cidr|ip"`
+	// TrustedProxyHeader is the only header read to find the client address,
+	// Forwarded or a list such as X-Forwarded-For. It must be the header the
+	// proxies overwrite.
+	TrustedProxyHeader string
+	// ShutdownDelay keeps serving after readiness turns false, giving load
+	// balancers time to stop routing to the instance.
+	ShutdownDelay       time.Duration
+	ShutdownGracePeriod time.Duration
+	ShutdownSignals     []os.Signal
+	// TLS serves HTTPS when set.
+	TLS *TLSOptions
+	// Upgrade enables binary upgrades through listener handoff, linux only.
+	Upgrade *UpgradeOptions
 }
 
 func NewRestServerOptions(bindAddress string, log logger.Logger) *Options {
-	return &Options{BindAddress: bindAddress, Log: log}
+	return &Options{
+		BindAddress: bindAddress,
+		Log:         log,
+		Binding:     BindingOptions{DisallowTrailingData: true},
+		Limits:      NewLimitOptions(),
+
+		TrustedProxyHeader: "X-Forwarded-For",
+
+		ShutdownGracePeriod: defaultShutdownGracePeriod,
+		ShutdownSignals:     []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+}
+
+func (o *Options) WithBinding(binding BindingOptions) *Options {
+	o.Binding = binding
+	return o
+}
+
+func (o *Options) WithLimits(limits LimitOptions) *Options {
+	o.Limits = limits
+	return o
+}
+
+func (o *Options) WithListener(listener ListenerOptions) *Options {
+	o.Listeners = append(o.Listeners, listener)
+	return o
+}
+
+func (o *Options) WithTrustedProxies(cidrs ...string) *Options {
+	o.TrustedProxies = cidrs
+	return o
+}
+
+func (o *Options) WithTrustedProxyHeader(header string) *Options {
+	o.TrustedProxyHeader = header
+	return o
+}
+
+func (o *Options) WithShutdown(delay time.Duration, gracePeriod time.Duration) *Options {
+	o.ShutdownDelay = delay
+	o.ShutdownGracePeriod = gracePeriod
+	return o
+}
+
+func (o *Options) WithTLS(tls *TLSOptions) *Options {
+	o.TLS = tls
+	return o
+}
+
+func (o *Options) WithUpgrade(upgrade *UpgradeOptions) *Options {
+	o.Upgrade = upgrade
+	return o
 }
@@ -2,43 +2,73 @@ package server
 
 import (
 	"context"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"github.com/yurikilian/bills/pkg/exception"
 	"github.com/yurikilian/bills/pkg/logger"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
 )
 
 type IHttpContext interface {
 	Writer() http.ResponseWriter
+	SetWriter(w http.ResponseWriter)
 	Request() *http.Request
+	Route() string
+	ClientIP() netip.Addr
+	ClientSubject() (pkix.Name, bool)
 	ReqCtx() context.Context
 	SetRequest(r *http.Request)
 	WriteResponse(statusCode int, data interface{}) error
 	Logger() logger.Logger
 	ReadBody(bodyStruct interface{}) error
-	reset(writer http.ResponseWriter, request *http.Request)
+	CheckPreconditions(etag string, lastModified time.Time) error
+	CspNonce() string
+	SetCspNonce(nonce string)
+	Clone() IHttpContext
+	reset(writer http.ResponseWriter, request *http.Request, route string)
 }
 
 type HttpContext struct {
-	writer  http.ResponseWriter
-	request *http.Request
-	log     logger.Logger
-	binder  *Binder
+	writer   http.ResponseWriter
+	request  *http.Request
+	route    string
+	cspNonce string
+	clientIP netip.Addr
+	log      logger.Logger
+	binder   *Binder
+	resolver *ClientIPResolver
 }
 
-func NewHttpContext(writer http.ResponseWriter, request *http.Request, log logger.Logger, binder *Binder) IHttpContext {
-	return &HttpContext{writer: writer, request: request, log: log, binder: binder}
+func NewHttpContext(writer http.ResponseWriter, request *http.Request, log logger.Logger, binder *Binder, resolver *ClientIPResolver) IHttpContext {
+	return &HttpContext{writer: writer, request: request, log: log, binder: binder, resolver: resolver}
 }
 
-func (hCtx *HttpContext) reset(writer http.ResponseWriter, request *http.Request) {
+func (hCtx *HttpContext) reset(writer http.ResponseWriter, request *http.Request, route string) {
 	hCtx.request = request
 	hCtx.writer = writer
+	hCtx.route = route
+	hCtx.cspNonce = ""
+	hCtx.clientIP = netip.Addr{}
+}
+
+// Clone returns a copy that is detached from the server context pool, so it
+// can outlive the request that created it.
+func (hCtx *HttpContext) Clone() IHttpContext {
+	clone := *hCtx
+	return &clone
 }
 
 func (hCtx *HttpContext) Writer() http.ResponseWriter {
 	return hCtx.writer
 }
 
+func (hCtx *HttpContext) SetWriter(w http.ResponseWriter) {
+	hCtx.writer = w
+}
+
 func (hCtx *HttpContext) Request() *http.Request {
 	return hCtx.request
 }
@@ -51,12 +81,59 @@ func (hCtx *HttpContext) SetRequest(r *http.Request) {
 	hCtx.request = r
 }
 
+func (hCtx *HttpContext) Route() string {
+	return hCtx.route
+}
+
+func (hCtx *HttpContext) ClientIP() netip.Addr {
+	if !hCtx.clientIP.IsValid() && hCtx.resolver != nil {
+		hCtx.clientIP = hCtx.resolver.Resolve(hCtx.request)
+	}
+	return hCtx.clientIP
+}
+
+// ClientSubject returns the subject of the certificate presented by the client
+// when the server runs with mutual TLS.
+func (hCtx *HttpContext) ClientSubject() (pkix.Name, bool) {
+	return clientSubject(hCtx.request.TLS)
+}
+
 func (hCtx *HttpContext) WriteResponse(statusCode int, data interface{}) error {
-	hCtx.writer.Header().Set("Content-Type", "application/json")
+	body, err := json.Marshal(data)
+	if err != nil {
+		return exception.NewInternalServerError(err.Error())
+	}
+	body = append(body, '\n')
+
+	header := hCtx.writer.Header()
+	if len(header.Get("Content-Type")) == 0 {
+		header.Set("Content-Type", "application/json")
+	}
+
+	if statusCode == http.StatusOK && isSafeMethod(hCtx.request.Method) {
+		if len(header.Get("ETag")) == 0 {
+			header.Set("ETag", WeakETag(body))
+		}
+
+		if isNotModified(hCtx.request, header) {
+			header.Del("Content-Type")
+			hCtx.writer.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	if hCtx.request.Method == http.MethodHead && bodyAllowedForStatus(statusCode) {
+		// HEAD announces the length GET would send.
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
 	hCtx.writer.WriteHeader(statusCode)
 
-	err := json.NewEncoder(hCtx.Writer()).Encode(data)
-	if err != nil {
+	if !bodyAllowedForStatus(statusCode) || hCtx.request.Method == http.MethodHead {
+		return nil
+	}
+
+	if _, err = hCtx.writer.Write(body); err != nil {
 		return exception.NewInternalServerError(err.Error())
 	}
 
@@ -69,3 +146,17 @@ func (hCtx *HttpContext) Logger() logger.Logger {
 func (hCtx *HttpContext) ReadBody(bodyStruct interface{}) error {
 	return hCtx.binder.ReadBody(hCtx, bodyStruct)
 }
+
+func (hCtx *HttpContext) CspNonce() string {
+	return hCtx.cspNonce
+}
+
+func (hCtx *HttpContext) SetCspNonce(nonce string) {
+	hCtx.cspNonce = nonce
+}
+
+// CheckPreconditions must be called by unsafe handlers with the current
+// validators of the resource before modifying it.
+func (hCtx *HttpContext) CheckPreconditions(etag string, lastModified time.Time) error {
+	return checkPreconditions(hCtx.request, etag, lastModified)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttpContext_WriteResponse_Body(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		statusCode         int
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{name: "Should write body given ok", method: http.MethodGet, statusCode: http.StatusOK, expectedStatusCode: http.StatusOK, expectedBody: "\"transaction\"\n"},
+		{name: "Should write body given created", method: http.MethodPost, statusCode: http.StatusCreated, expectedStatusCode: http.StatusCreated, expectedBody: "\"transaction\"\n"},
+		{name: "Should omit body given no content", method: http.MethodPost, statusCode: http.StatusNoContent, expectedStatusCode: http.StatusNoContent},
+		{name: "Should omit body given not modified", method: http.MethodGet, statusCode: http.StatusNotModified, expectedStatusCode: http.StatusNotModified},
+		{name: "Should omit body given head request", method: http.MethodHead, statusCode: http.StatusOK, expectedStatusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ctx := NewHttpContext(rec, httptest.NewRequest(tt.method, "/transactions", nil), logger.NewProvider().ProvideLog(), nil, nil)
+
+			err := ctx.WriteResponse(tt.statusCode, "transaction")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
@@ -3,11 +3,13 @@ package server
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/yurikilian/bills/pkg/exception"
 	"go.opentelemetry.io/otel/trace"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 )
 
 type Middleware func(next HttpMethodHandler) HttpMethodHandler
@@ -24,8 +26,26 @@ type RestServer struct {
 	server      *http.Server
 	middlewares []func(next HttpMethodHandler) HttpMethodHandler
 	binder      *Binder
+	ipResolver  *ClientIPResolver
 	ctxPool     sync.Pool
 
+	listenersMu sync.Mutex
+	listeners   []net.Listener
+	attached    []net.Listener
+	inheritable []net.Listener
+	attachments []attachment
+	upgradeMu   sync.Mutex
+
+	ready         atomic.Bool
+	readyCh       chan struct{}
+	readyOnce     sync.Once
+	startHooks    []Hook
+	readyHooks    []Hook
+	stopHooks     []Hook
+	shutdownOnce  sync.Once
+	shutdownErr   error
+	shutdownHooks []ShutdownHook
+
 	configuration *RestServerConfiguration
 	options       *Options
 }
@@ -33,14 +53,16 @@ type RestServer struct {
 func NewRestServer(options *Options) *RestServer {
 
 	srv := &RestServer{
-		mux:     http.NewServeMux(),
-		server:  &http.Server{Addr: options.BindAddress},
-		binder:  NewBinder(),
-		options: options,
+		mux:        http.NewServeMux(),
+		server:     &http.Server{Addr: options.BindAddress},
+		binder:     NewBinder(options.Binding),
+		ipResolver: NewClientIPResolver(options.TrustedProxyHeader, options.TrustedProxies),
+		readyCh:    make(chan struct{}),
+		options:    options,
 	}
 
 	srv.ctxPool.New = func() interface{} {
-		return NewHttpContext(nil, nil, srv.options.Log, srv.binder)
+		return NewHttpContext(nil, nil, srv.options.Log, srv.binder, srv.ipResolver)
 	}
 
 	return srv
@@ -51,6 +73,14 @@ func (srv *RestServer) Router(router *RestRouter) *RestServer {
 	return srv
 }
 
+// RestRouter returns the server router, creating an empty one if none was set.
+func (srv *RestServer) RestRouter() *RestRouter {
+	if srv.router == nil {
+		srv.router = NewRestRouter()
+	}
+	return srv.router
+}
+
 func (srv *RestServer) Use(middleware Middleware) *RestServer {
 	srv.middlewares = append(srv.middlewares, middleware)
 	return srv
@@ -59,9 +89,8 @@ func (srv *RestServer) Use(middleware Middleware) *RestServer {
 func (srv *RestServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	httpContext := srv.ctxPool.Get().(IHttpContext)
-	httpContext.reset(w, req)
-
-	handler := srv.getHandler(req)
+	handler, route := srv.getHandler(req)
+	httpContext.reset(w, req, route)
 
 	err := srv.applyMiddlewares(handler)(httpContext)
 
@@ -87,15 +116,15 @@ func (srv *RestServer) process(handler HttpMethodHandler, httpContext IHttpConte
 	}
 }
 
-func (srv *RestServer) getHandler(req *http.Request) HttpMethodHandler {
-	httpMethodHandler, status := srv.router.load(req.URL.Path, req.Method)
+func (srv *RestServer) getHandler(req *http.Request) (HttpMethodHandler, string) {
+	httpMethodHandler, route, status := srv.router.match(req.URL.Path, req.Method)
 
 	if status == Matched {
-		return httpMethodHandler
+		return httpMethodHandler, route
 	} else if status == PathNotFound {
-		return srv.errorHandler(exception.NewRouteNotFound(req.URL.Path))
+		return srv.errorHandler(exception.NewRouteNotFound(req.URL.Path)), route
 	} else {
-		return srv.errorHandler(exception.NewMethodNotAllowed(req.URL.Path, req.Method))
+		return srv.errorHandler(exception.NewMethodNotAllowed(req.URL.Path, req.Method)), route
 	}
 }
 
@@ -128,26 +157,18 @@ func (srv *RestServer) applyMiddlewares(handlerByMethod HttpMethodHandler) HttpM
 	return fnc
 }
 
+// Start blocks until the server stops. ok is false when the options are
+// invalid, otherwise the problem describes why the server stopped. Prefer
+// Run, which reports a graceful stop as a nil error.
 func (srv *RestServer) Start(ctx context.Context) (exception.Problem, bool) {
-
-	err := Validator.Validate(srv.options)
-	if err != nil {
-		return exception.NewInternalServerError(err.Error()), false
+	err := srv.Run(ctx)
+	if errors.Is(err, ErrInvalidOptions) {
+		return srv.handleError(errors.Unwrap(err)), false
 	}
-
-	srv.mux.Handle("/", srv)
-	srv.server.Handler = srv.mux
-
-	srv.options.Log.Info(ctx, fmt.Sprintf("Starting server on %v address", srv.options.BindAddress))
-
-	return srv.handleError(srv.server.ListenAndServe()), true
-}
-
-func (srv *RestServer) Shutdown(ctx context.Context) {
-	err := srv.server.Shutdown(ctx)
-	if err != nil {
-		panic(err)
+	if err == nil {
+		err = http.ErrServerClosed
 	}
+	return srv.handleError(err), true
 }
 
 func (srv *RestServer) handleError(err error) exception.Problem {
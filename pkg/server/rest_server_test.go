@@ -157,6 +157,16 @@ func TestRestServer_Start(t *testing.T) {
 			expectedErr:   exception.NewInternalServerError("Key: 'Options.Log' Error:Field validation for 'Log' failed on the 'required' tag"),
 			expectedStart: false,
 		},
+		{
+			name: "Return exception given negative server timeouts",
+			fields: fields{
+				router: NewRestRouter(),
+				server: NewRestServer(NewRestServerOptions(":0", logger.NewProvider().ProvideLog()).
+					WithLimits(LimitOptions{ReadTimeout: -time.Second})),
+			},
+			expectedErr:   exception.NewInternalServerError("Key: 'Options.Limits.ReadTimeout' Error:Field validation for 'ReadTimeout' failed on the 'gte' tag"),
+			expectedStart: false,
+		},
 		{
 			name: "Should start server",
 			fields: fields{
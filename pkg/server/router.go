@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/yurikilian/bills/pkg/matcher"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +17,7 @@ const (
 
 type RestRouter struct {
 	routes Routes
+	prefix string
 }
 
 func NewRestRouter() *RestRouter {
@@ -32,7 +34,24 @@ func (r *RestRouter) POST(path string, handlerFunc HttpMethodHandler) *RestRoute
 	return r
 }
 
+// Group returns a router registering its routes under prefix in the same
+// route table.
+func (r *RestRouter) Group(prefix string) *RestRouter {
+	return &RestRouter{routes: r.routes, prefix: joinPath(r.prefix, prefix)}
+}
+
+func joinPath(prefix string, path string) string {
+	joined := strings.TrimRight(prefix, "/") + "/" + strings.TrimLeft(path, "/")
+	if len(joined) > 1 {
+		joined = strings.TrimRight(joined, "/")
+	}
+	return joined
+}
+
 func (r *RestRouter) register(path string, httpMethod string, handlerFunc HttpMethodHandler) {
+	if len(r.prefix) > 0 {
+		path = joinPath(r.prefix, path)
+	}
 	_, pathExists := r.routes[path]
 	if !pathExists {
 		r.routes[path] = map[string]HttpMethodHandler{}
@@ -41,30 +60,62 @@ func (r *RestRouter) register(path string, httpMethod string, handlerFunc HttpMe
 	r.routes[path][httpMethod] = handlerFunc
 }
 
+type Route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Routes lists the registered routes sorted by path and method.
+func (r *RestRouter) Routes() []Route {
+	routes := make([]Route, 0, len(r.routes))
+	for path, handlers := range r.routes {
+		for method := range handlers {
+			routes = append(routes, Route{Method: method, Path: path})
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
 func (r *RestRouter) load(path, method string) (HttpMethodHandler, LoadStatus) {
-	handlersByPath, ok := r.matchHandlers(strings.Split(path, "/"))
+	httpMethodHandler, _, status := r.match(path, method)
+	return httpMethodHandler, status
+}
+
+func (r *RestRouter) match(path, method string) (HttpMethodHandler, string, LoadStatus) {
+	pattern, handlersByPath, ok := r.matchHandlers(strings.Split(path, "/"))
 	//handlersByPath, ok := r.routes[path]
 	if !ok {
-		return nil, PathNotFound
+		return nil, "", PathNotFound
 	}
 
 	httpMethodHandler, ok := handlersByPath[method]
+	if !ok && method == http.MethodHead {
+		// HEAD is answered by the GET handler, the body is never sent.
+		httpMethodHandler, ok = handlersByPath[http.MethodGet]
+	}
 	if !ok {
-		return nil, MethodNotAllowed
+		return nil, pattern, MethodNotAllowed
 	}
 
-	return httpMethodHandler, Matched
+	return httpMethodHandler, pattern, Matched
 }
 
 // TODO remove 4 allocs
-func (r *RestRouter) matchHandlers(pathParts []string) (HandlersByPath, bool) {
+func (r *RestRouter) matchHandlers(pathParts []string) (string, HandlersByPath, bool) {
 
 	for pattern, methods := range r.routes {
 
 		if matcher.MatchPath(pathParts, pattern) {
-			return methods, true
+			return pattern, methods, true
 		}
 	}
 
-	return nil, false
+	return "", nil, false
 }
@@ -113,6 +113,15 @@ func TestRestRouter_load(t *testing.T) {
 			},
 			expectedStatus: Matched,
 		},
+		{
+			name:   "Should return get handler given head method",
+			fields: fields{routeMap: routeMap},
+			args: args{
+				path:       "/transactions",
+				httpMethod: http.MethodHead,
+			},
+			expectedStatus: Matched,
+		},
 		{
 			name:   "Should match url /transactions/:id/product/:productId - URL with Path variables",
 			fields: fields{routeMap: routeMap},
@@ -196,3 +205,18 @@ func Test_MatchPatchVariables(t *testing.T) {
 func trnProductWithIdFunc(ctx IHttpContext) error {
 	return errors.New("just a test")
 }
+
+func TestRestRouter_Group(t *testing.T) {
+	router := NewRestRouter()
+	router.Group("/api").Group("transactions/").
+		Get("/", emptyHandlerFunc).
+		POST("/:id", emptyHandlerFunc)
+
+	assert.Equal(t, []Route{
+		{Method: http.MethodGet, Path: "/api/transactions"},
+		{Method: http.MethodPost, Path: "/api/transactions/:id"},
+	}, router.Routes())
+
+	_, status := router.load("/api/transactions/10", http.MethodPost)
+	assert.Equal(t, Matched, status)
+}
@@ -0,0 +1,118 @@
+// Package servertest drives a RestServer in memory, without binding a port.
+//
+//	servertest.New(t, srv).
+//		Post("/transactions").
+//		WithJSON(request).
+//		Do().
+//		AssertStatus(http.StatusNoContent)
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type Client struct {
+	t       testing.TB
+	handler http.Handler
+	header  http.Header
+}
+
+// New returns a client sending every request to handler, usually a
+// *server.RestServer.
+func New(t testing.TB, handler http.Handler) *Client {
+	return &Client{t: t, handler: handler, header: http.Header{}}
+}
+
+// WithHeader sets a header sent with every request of the client.
+func (c *Client) WithHeader(key string, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+func (c *Client) Get(path string) *Request {
+	return c.Request(http.MethodGet, path)
+}
+
+func (c *Client) Head(path string) *Request {
+	return c.Request(http.MethodHead, path)
+}
+
+func (c *Client) Post(path string) *Request {
+	return c.Request(http.MethodPost, path)
+}
+
+func (c *Client) Put(path string) *Request {
+	return c.Request(http.MethodPut, path)
+}
+
+func (c *Client) Patch(path string) *Request {
+	return c.Request(http.MethodPatch, path)
+}
+
+func (c *Client) Delete(path string) *Request {
+	return c.Request(http.MethodDelete, path)
+}
+
+func (c *Client) Request(method string, path string) *Request {
+	return &Request{client: c, method: method, path: path, header: c.header.Clone()}
+}
+
+type Request struct {
+	client *Client
+	method string
+	path   string
+	header http.Header
+	body   []byte
+}
+
+func (r *Request) WithHeader(key string, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithJSON marshals body as the request payload.
+func (r *Request) WithJSON(body interface{}) *Request {
+	r.client.t.Helper()
+
+	payload, err := json.Marshal(body)
+	require.NoError(r.client.t, err)
+
+	r.body = payload
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	r.body = body
+	r.header.Set("Content-Type", contentType)
+	return r
+}
+
+// Do serves the request and records the response.
+func (r *Request) Do() *Response {
+	r.client.t.Helper()
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	req := httptest.NewRequest(r.method, r.path, body)
+	req.Header = r.header
+
+	recorder := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(recorder, req)
+
+	return &Response{
+		t:          r.client.t,
+		StatusCode: recorder.Code,
+		Header:     recorder.Header(),
+		Body:       recorder.Body.Bytes(),
+	}
+}
@@ -0,0 +1,66 @@
+package servertest_test
+
+import (
+	"github.com/yurikilian/bills/internal/logger"
+	"github.com/yurikilian/bills/pkg/exception"
+	"github.com/yurikilian/bills/pkg/server"
+	"github.com/yurikilian/bills/pkg/server/servertest"
+	"net/http"
+	"testing"
+)
+
+type greeting struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func newServer() *server.RestServer {
+	return server.NewRestServer(server.NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(server.NewRestRouter().
+			Get("/greetings", func(ctx server.IHttpContext) error {
+				ctx.Writer().Header().Set("X-Greeting", ctx.Request().Header.Get("Accept-Language"))
+				return ctx.WriteResponse(http.StatusOK, map[string]interface{}{
+					"items": []greeting{{Name: "hello"}, {Name: "olá"}},
+				})
+			}).
+			POST("/greetings", func(ctx server.IHttpContext) error {
+				var request greeting
+				if err := ctx.ReadBody(&request); err != nil {
+					return err
+				}
+				return ctx.WriteResponse(http.StatusCreated, request)
+			}))
+}
+
+func TestClient(t *testing.T) {
+	client := servertest.New(t, newServer()).WithHeader("Accept-Language", "pt")
+
+	client.Get("/greetings").
+		Do().
+		AssertStatus(http.StatusOK).
+		AssertHeader("Content-Type", "application/json").
+		AssertHeader("X-Greeting", "pt").
+		AssertJSONPath("items.1.name", "olá").
+		AssertJSONPath("items", []greeting{{Name: "hello"}, {Name: "olá"}}).
+		AssertGolden("greetings")
+
+	var created greeting
+	client.Post("/greetings").
+		WithJSON(greeting{Name: "hi"}).
+		Do().
+		AssertStatus(http.StatusCreated).
+		Decode(&created)
+	if created.Name != "hi" {
+		t.Errorf("created = %v", created)
+	}
+
+	client.Post("/greetings").
+		WithJSON(greeting{}).
+		Do().
+		AssertProblem(exception.NewValidationProblem([]exception.ValidationProblemDetail{
+			exception.NewValidationProblemDetail("required", "Name", ""),
+		}))
+
+	client.Get("/unknown").
+		Do().
+		AssertProblem(exception.NewRouteNotFound("/unknown"))
+}
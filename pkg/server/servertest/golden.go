@@ -0,0 +1,38 @@
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+)
+
+// UpdateGoldenEnv rewrites the golden files instead of comparing them when set
+// to true, e.g. SERVERTEST_UPDATE=true go test ./...
+const UpdateGoldenEnv = "SERVERTEST_UPDATE"
+
+// AssertGolden compares the body with testdata/<name>.golden. JSON bodies are
+// indented first so the files stay readable and diffs stay small.
+func (r *Response) AssertGolden(name string) *Response {
+	r.t.Helper()
+
+	body := r.Body
+	var indented bytes.Buffer
+	if json.Valid(body) && json.Indent(&indented, body, "", "  ") == nil {
+		body = append(bytes.TrimSpace(indented.Bytes()), '\n')
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateGoldenEnv) == "true" {
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(r.t, os.WriteFile(path, body, 0644))
+		return r
+	}
+
+	golden, err := os.ReadFile(path)
+	require.NoError(r.t, err, "missing golden file, run the test with %v=true to create it", UpdateGoldenEnv)
+	assert.Equal(r.t, string(golden), string(body), "body differs from %v", path)
+	return r
+}
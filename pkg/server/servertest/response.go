@@ -0,0 +1,117 @@
+package servertest
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/pkg/exception"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type Response struct {
+	t          testing.TB
+	StatusCode int
+	Header     map[string][]string
+	Body       []byte
+}
+
+func (r *Response) AssertStatus(statusCode int) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, statusCode, r.StatusCode, "unexpected status, body: %s", r.Body)
+	return r
+}
+
+func (r *Response) AssertHeader(key string, value string) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, value, headerValue(r.Header, key), "unexpected %v header", key)
+	return r
+}
+
+// Decode unmarshals the JSON body into v.
+func (r *Response) Decode(v interface{}) *Response {
+	r.t.Helper()
+	require.NoError(r.t, json.Unmarshal(r.Body, v), "body is not valid JSON: %s", r.Body)
+	return r
+}
+
+// AssertJSONPath compares the value at a dot separated path, such as
+// "items.0.title", with want once both are converted to their JSON form.
+func (r *Response) AssertJSONPath(path string, want interface{}) *Response {
+	r.t.Helper()
+
+	var document interface{}
+	r.Decode(&document)
+
+	got, ok := lookup(document, path)
+	if !assert.True(r.t, ok, "path %q not found in %s", path, r.Body) {
+		return r
+	}
+
+	assert.Equal(r.t, normalize(r.t, want), got, "unexpected value at %q", path)
+	return r
+}
+
+// Problem decodes the body as a problem.
+func (r *Response) Problem() exception.Problem {
+	r.t.Helper()
+
+	var problem exception.Problem
+	r.Decode(&problem)
+	return problem
+}
+
+// AssertProblem checks the status code and the problem body.
+func (r *Response) AssertProblem(expected exception.Problem) *Response {
+	r.t.Helper()
+
+	r.AssertStatus(expected.Code)
+	exception.AssertProblem(r.t, expected, r.Problem())
+	return r
+}
+
+func headerValue(header map[string][]string, key string) string {
+	for k, values := range header {
+		if strings.EqualFold(k, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func lookup(document interface{}, path string) (interface{}, bool) {
+	if len(path) == 0 {
+		return document, true
+	}
+
+	current := document
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func normalize(t testing.TB, value interface{}) interface{} {
+	payload, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	var normalized interface{}
+	require.NoError(t, json.Unmarshal(payload, &normalized))
+	return normalized
+}
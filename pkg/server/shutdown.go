@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ShutdownHook releases a resource, such as a database pool or a telemetry
+// exporter, once the server stopped serving requests.
+type ShutdownHook = Hook
+
+func (srv *RestServer) OnShutdown(hook ShutdownHook) *RestServer {
+	srv.shutdownHooks = append(srv.shutdownHooks, hook)
+	return srv
+}
+
+// IsReady reports whether the server is accepting traffic. It turns false as
+// soon as a shutdown starts so readiness probes fail while draining.
+func (srv *RestServer) IsReady() bool {
+	return srv.ready.Load()
+}
+
+// Shutdown stops accepting connections, waits for in-flight requests during
+// the grace period and runs the shutdown hooks in reverse registration order.
+// Later calls return the result of the first one.
+func (srv *RestServer) Shutdown(ctx context.Context) error {
+	srv.shutdownOnce.Do(func() {
+		srv.shutdownErr = srv.shutdown(ctx)
+	})
+	return srv.shutdownErr
+}
+
+func (srv *RestServer) shutdown(ctx context.Context) error {
+	srv.ready.Store(false)
+
+	errs := srv.stop(ctx)
+
+	if delay := srv.options.ShutdownDelay; delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	drainCtx, cancel := context.WithTimeout(ctx, srv.gracePeriod())
+	defer cancel()
+
+	if err := srv.server.Shutdown(drainCtx); err != nil {
+		errs = append(errs, fmt.Errorf("could not drain connections: %w", err))
+		if err := srv.server.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("could not close connections: %w", err))
+		}
+	}
+
+	errs = append(errs, srv.shutdownAttached(drainCtx)...)
+
+	errs = append(errs, srv.release(ctx)...)
+	return errors.Join(errs...)
+}
+
+// release runs the shutdown hooks in reverse registration order, bounded by
+// the grace period.
+func (srv *RestServer) release(ctx context.Context) []error {
+	hookCtx, cancel := context.WithTimeout(ctx, srv.gracePeriod())
+	defer cancel()
+
+	var errs []error
+	for i := len(srv.shutdownHooks) - 1; i >= 0; i-- {
+		if err := srv.shutdownHooks[i](hookCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// stop runs the stop hooks in reverse registration order, bounded by the
+// grace period.
+func (srv *RestServer) stop(ctx context.Context) []error {
+	stopCtx, cancel := context.WithTimeout(ctx, srv.gracePeriod())
+	defer cancel()
+
+	var errs []error
+	for i := len(srv.stopHooks) - 1; i >= 0; i-- {
+		if err := srv.stopHooks[i](stopCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// abort runs the stop and shutdown hooks when the server fails after its
+// start hooks ran, so resources acquired since are released. It counts as
+// the shutdown, later Shutdown calls return its result.
+func (srv *RestServer) abort(err error) error {
+	srv.shutdownOnce.Do(func() {
+		srv.ready.Store(false)
+		errs := srv.stop(context.Background())
+		errs = append(errs, srv.release(context.Background())...)
+		srv.shutdownErr = errors.Join(errs...)
+	})
+	if srv.shutdownErr != nil {
+		return errors.Join(err, srv.shutdownErr)
+	}
+	return err
+}
+
+func (srv *RestServer) gracePeriod() time.Duration {
+	if srv.options.ShutdownGracePeriod <= 0 {
+		return defaultShutdownGracePeriod
+	}
+	return srv.options.ShutdownGracePeriod
+}
+
+// shutdownSignals never returns an empty list, as subscribing without signals
+// would relay every signal the process receives.
+func (srv *RestServer) shutdownSignals() []os.Signal {
+	if len(srv.options.ShutdownSignals) == 0 {
+		return []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	return srv.options.ShutdownSignals
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/yurikilian/bills/internal/logger"
+	"testing"
+	"time"
+)
+
+func TestRestServer_Shutdown(t *testing.T) {
+	closed := make([]string, 0)
+	deadlines := make([]string, 0)
+	server := NewRestServer(NewRestServerOptions(":0", logger.NewProvider().ProvideLog()).WithShutdown(0, time.Second)).
+		Router(NewRestRouter()).
+		OnStop(func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); ok {
+				deadlines = append(deadlines, "stop")
+			}
+			return nil
+		}).
+		OnShutdown(func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); ok {
+				deadlines = append(deadlines, "shutdown")
+			}
+			closed = append(closed, "database")
+			return nil
+		}).
+		OnShutdown(func(ctx context.Context) error {
+			closed = append(closed, "telemetry")
+			return errors.New("exporter unavailable")
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		_, ok := server.Start(ctx)
+		assert.True(t, ok)
+	}()
+
+	assert.Eventually(t, server.IsReady, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop after the context was cancelled")
+	}
+
+	assert.False(t, server.IsReady())
+	assert.Equal(t, []string{"telemetry", "database"}, closed)
+	assert.Equal(t, []string{"stop", "shutdown"}, deadlines)
+	assert.EqualError(t, server.Shutdown(context.Background()), "exporter unavailable")
+}
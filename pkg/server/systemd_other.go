@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package server
+
+import "net"
+
+// SystemdListeners returns no listener, socket activation is not available on
+// this platform.
+func SystemdListeners() ([]net.Listener, error) {
+	return nil, nil
+}
@@ -0,0 +1,55 @@
+//go:build linux || darwin
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const systemdListenFdsStart = 3
+
+// SystemdListeners returns the sockets passed by systemd socket activation,
+// in the order of the unit ListenStream directives. It returns no listener
+// when the process was not socket activated.
+func SystemdListeners() ([]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]net.Listener, 0, count)
+	for fd := systemdListenFdsStart; fd < systemdListenFdsStart+count; fd++ {
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i := fd - systemdListenFdsStart; i < len(names) && len(names[i]) > 0 {
+			name = names[i]
+		}
+
+		syscall.CloseOnExec(fd)
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("could not use systemd socket %v: %w", name, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
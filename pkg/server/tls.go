@@ -0,0 +1,177 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultCertReloadInterval = 30 * time.Second
+
+type TLSOptions struct {
+	CertFile string `validate:"required,file"`
+	KeyFile  string `validate:"required,file"`
+	// MinVersion defaults to TLS 1.2.
+	MinVersion uint16 `validate:"omitempty,oneof=769 770 771 772"`
+	// CipherSuites only applies to TLS 1.2 and below, TLS 1.3 suites are not configurable.
+	CipherSuites []uint16
+	// ClientCAFile enables mutual TLS: clients must present a certificate
+	// signed by one of the bundled CAs.
+	ClientCAFile string `validate:"omitempty,file"`
+	// ReloadInterval bounds how often certificate files are checked for changes.
+	ReloadInterval time.Duration
+}
+
+func NewTLSOptions(certFile string, keyFile string) *TLSOptions {
+	return &TLSOptions{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		MinVersion:     tls.VersionTLS12,
+		ReloadInterval: defaultCertReloadInterval,
+	}
+}
+
+func (o *TLSOptions) WithMinVersion(version uint16) *TLSOptions {
+	o.MinVersion = version
+	return o
+}
+
+func (o *TLSOptions) WithCipherSuites(suites ...uint16) *TLSOptions {
+	o.CipherSuites = suites
+	return o
+}
+
+func (o *TLSOptions) WithClientCA(caFile string) *TLSOptions {
+	o.ClientCAFile = caFile
+	return o
+}
+
+// Config builds the tls.Config used by the server. Certificates are reloaded
+// on handshake once the files change on disk.
+func (o *TLSOptions) Config() (*tls.Config, error) {
+	for _, id := range o.CipherSuites {
+		if !isSecureCipherSuite(id) {
+			return nil, fmt.Errorf("cipher suite %#04x is not supported", id)
+		}
+	}
+
+	reloader, err := newCertReloader(o.CertFile, o.KeyFile, o.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     o.MinVersion,
+		CipherSuites:   o.CipherSuites,
+		GetCertificate: reloader.getCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	if len(o.ClientCAFile) > 0 {
+		bundle, err := os.ReadFile(o.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in client CA bundle %v", o.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+func isSecureCipherSuite(id uint16) bool {
+	for _, suite := range tls.CipherSuites() {
+		if suite.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modTimes    [2]time.Time
+	checkedAt   time.Time
+}
+
+func newCertReloader(certFile string, keyFile string, interval time.Duration) (*certReloader, error) {
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) >= r.interval {
+		// A broken pair on disk, e.g. while the files are being rotated,
+		// keeps the previous certificate in use.
+		_ = r.reload()
+	}
+	return r.certificate, nil
+}
+
+func (r *certReloader) reload() error {
+	r.checkedAt = time.Now()
+
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+	if r.certificate != nil && modTimes[0].Equal(r.modTimes[0]) && modTimes[1].Equal(r.modTimes[1]) {
+		return nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.certificate = &certificate
+	r.modTimes = modTimes
+	return nil
+}
+
+func (r *certReloader) stat() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// clientSubject returns the subject of the verified client certificate, if any.
+func clientSubject(state *tls.ConnectionState) (pkix.Name, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return pkix.Name{}, false
+	}
+	return state.VerifiedChains[0][0].Subject, true
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/internal/logger"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"bills"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCertificate{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c *testCertificate) keyPem(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCertificate) tlsCertificate(t *testing.T) tls.Certificate {
+	certificate, err := tls.X509KeyPair(c.pem, c.keyPem(t))
+	require.NoError(t, err)
+	return certificate
+}
+
+func writeKeyPair(t *testing.T, dir string, c *testCertificate, modTime time.Time) (string, string) {
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, c.pem, 0600))
+	require.NoError(t, os.WriteFile(keyFile, c.keyPem(t), 0600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	return certFile, keyFile
+}
+
+func TestTLSOptions_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "bills-ca", nil)
+	certFile, keyFile := writeKeyPair(t, dir, newTestCertificate(t, "localhost", ca), time.Now())
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+
+	config, err := NewTLSOptions(certFile, keyFile).WithClientCA(caFile).Config()
+	require.NoError(t, err)
+
+	server := NewRestServer(NewRestServerOptions(":0", logger.NewProvider().ProvideLog())).
+		Router(NewRestRouter().Get("/", func(ctx IHttpContext) error {
+			subject, ok := ctx.ClientSubject()
+			return ctx.WriteResponse(http.StatusOK, map[string]interface{}{"verified": ok, "subject": subject.CommonName})
+		}))
+
+	ts := httptest.NewUnstartedServer(server)
+	ts.TLS = config
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	tests := []struct {
+		name         string
+		certificates []tls.Certificate
+		wantErr      bool
+		wantBody     string
+	}{
+		{
+			name:         "Should expose the verified client subject",
+			certificates: []tls.Certificate{newTestCertificate(t, "billing-worker", ca).tlsCertificate(t)},
+			wantBody:     `{"subject":"billing-worker","verified":true}` + "\n",
+		},
+		{
+			name:    "Should reject clients without certificate",
+			wantErr: true,
+		},
+		{
+			name:         "Should reject certificates signed by an unknown CA",
+			certificates: []tls.Certificate{newTestCertificate(t, "intruder", newTestCertificate(t, "other-ca", nil)).tlsCertificate(t)},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      roots,
+				Certificates: tt.certificates,
+				// httptest installs its own certificate, which is served when no SNI is sent.
+				ServerName: "localhost",
+			}}}
+
+			res, err := client.Get(ts.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body := new(strings.Builder)
+			_, _ = io.Copy(body, res.Body)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, tt.wantBody, body.String())
+		})
+	}
+}
+
+func TestTLSOptions_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "bills-ca", nil)
+	certFile, keyFile := writeKeyPair(t, dir, newTestCertificate(t, "first", ca), time.Now().Add(-time.Minute))
+
+	options := NewTLSOptions(certFile, keyFile)
+	options.ReloadInterval = time.Nanosecond
+	config, err := options.Config()
+	require.NoError(t, err)
+
+	served := func() string {
+		certificate, err := config.GetCertificate(&tls.ClientHelloInfo{})
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		require.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+	assert.Equal(t, "first", served())
+
+	writeKeyPair(t, dir, newTestCertificate(t, "second", ca), time.Now())
+	assert.Equal(t, "second", served())
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("rotating"), 0600))
+	require.NoError(t, os.Chtimes(keyFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	assert.Equal(t, "second", served(), "a broken pair keeps the previous certificate")
+}
+
+func TestRestServer_Start_InvalidTLS(t *testing.T) {
+	options := NewRestServerOptions(":0", logger.NewProvider().ProvideLog()).
+		WithTLS(NewTLSOptions("missing.crt", "missing.key"))
+
+	problem, ok := NewRestServer(options).Router(NewRestRouter()).Start(context.Background())
+
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusInternalServerError, problem.Code)
+}
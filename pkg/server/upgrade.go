@@ -0,0 +1,43 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+const (
+	upgradeListenFdsEnv = "UPGRADE_LISTEN_FDS"
+	upgradeReadyFdEnv   = "UPGRADE_READY_FD"
+	upgradeFdsStart     = 3
+)
+
+var ErrUpgradeUnsupported = errors.New("binary upgrade is only supported on linux")
+
+// UpgradeOptions enables zero downtime binary upgrades: on one of the signals
+// the server starts a new process of the executable, hands it the bound
+// listeners and drains once the new process is serving.
+type UpgradeOptions struct {
+	Signals []os.Signal
+	// Executable defaults to the running binary, so replacing it on disk and
+	// signalling the process is enough to roll out a new version.
+	Executable string
+	Args       []string
+	// ReadyTimeout bounds the wait for the new process to start serving, it
+	// is killed when the timeout expires and the current process keeps going.
+	ReadyTimeout time.Duration `validate:"gte=0"`
+}
+
+func NewUpgradeOptions() *UpgradeOptions {
+	return &UpgradeOptions{
+		Signals:      defaultUpgradeSignals(),
+		Args:         os.Args[1:],
+		ReadyTimeout: 30 * time.Second,
+	}
+}
+
+// IsUpgradeChild reports whether the process was started by a binary upgrade
+// and inherits its listeners from the previous process.
+func IsUpgradeChild() bool {
+	return len(os.Getenv(upgradeListenFdsEnv)) > 0
+}
@@ -0,0 +1,197 @@
+//go:build linux
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func defaultUpgradeSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP, syscall.SIGUSR2}
+}
+
+// Upgrade starts a new process of the executable with the bound listeners and
+// waits until it is serving. The caller then drains and exits, Start does so
+// on its own when it receives an upgrade signal. On failure the new process
+// is killed and the current one keeps serving.
+func (srv *RestServer) Upgrade(ctx context.Context) error {
+	srv.upgradeMu.Lock()
+	defer srv.upgradeMu.Unlock()
+
+	if !srv.IsReady() {
+		return errors.New("server is not serving")
+	}
+
+	options := srv.options.Upgrade
+	if options == nil {
+		options = NewUpgradeOptions()
+	}
+
+	executable := options.Executable
+	if len(executable) == 0 {
+		var err error
+		if executable, err = os.Executable(); err != nil {
+			return err
+		}
+	}
+
+	files, err := srv.listenerFiles()
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyReader.Close()
+
+	cmd := exec.Command(executable, options.Args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(files, readyWriter)
+	cmd.Env = append(withoutUpgradeEnv(os.Environ()),
+		fmt.Sprintf("%v=%d", upgradeListenFdsEnv, len(files)),
+		fmt.Sprintf("%v=%d", upgradeReadyFdEnv, upgradeFdsStart+len(files)),
+	)
+
+	err = cmd.Start()
+	_ = readyWriter.Close()
+	if err != nil {
+		return err
+	}
+	srv.options.Log.Info(ctx, fmt.Sprintf("Started process %d for binary upgrade, waiting until it is ready", cmd.Process.Pid))
+
+	timeout := options.ReadyTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		// The read fails with EOF when the process exits before notifying.
+		_, err := readyReader.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	select {
+	case err = <-ready:
+		if err != nil {
+			err = fmt.Errorf("process %d exited before it was ready: %w", cmd.Process.Pid, err)
+		}
+	case <-time.After(timeout):
+		err = fmt.Errorf("process %d was not ready after %v", cmd.Process.Pid, timeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	// The new process owns the unix sockets from now on.
+	srv.listenersMu.Lock()
+	for _, listener := range srv.inheritable {
+		if unixListener, ok := listener.(*net.UnixListener); ok {
+			unixListener.SetUnlinkOnClose(false)
+		}
+	}
+	srv.listenersMu.Unlock()
+
+	srv.options.Log.Info(ctx, fmt.Sprintf("Process %d is ready, handing over", cmd.Process.Pid))
+	return nil
+}
+
+// listenerFiles duplicates the listener sockets, in the order they were bound.
+func (srv *RestServer) listenerFiles() ([]*os.File, error) {
+	srv.listenersMu.Lock()
+	defer srv.listenersMu.Unlock()
+
+	files := make([]*os.File, 0, len(srv.inheritable))
+	for _, listener := range srv.inheritable {
+		filer, ok := listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return files, fmt.Errorf("listener %v cannot be handed over", listener.Addr())
+		}
+
+		file, err := filer.File()
+		if err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func withoutUpgradeEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, value := range environ {
+		if strings.HasPrefix(value, upgradeListenFdsEnv+"=") || strings.HasPrefix(value, upgradeReadyFdEnv+"=") {
+			continue
+		}
+		env = append(env, value)
+	}
+	return env
+}
+
+// inheritedListeners returns the listeners handed over by the previous process.
+func inheritedListeners() ([]net.Listener, error) {
+	value := os.Getenv(upgradeListenFdsEnv)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	_ = os.Unsetenv(upgradeListenFdsEnv)
+
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v: %w", upgradeListenFdsEnv, err)
+	}
+
+	listeners := make([]net.Listener, 0, count)
+	for fd := upgradeFdsStart; fd < upgradeFdsStart+count; fd++ {
+		file := os.NewFile(uintptr(fd), "listener-"+strconv.Itoa(fd))
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("could not use inherited listener %d: %w", fd, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// notifyUpgradeParent tells the previous process it can drain and exit.
+func notifyUpgradeParent() error {
+	value := os.Getenv(upgradeReadyFdEnv)
+	if len(value) == 0 {
+		return nil
+	}
+	_ = os.Unsetenv(upgradeReadyFdEnv)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %v: %w", upgradeReadyFdEnv, err)
+	}
+
+	file := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer file.Close()
+
+	_, err = file.Write([]byte{1})
+	return err
+}
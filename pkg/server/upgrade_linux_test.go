@@ -0,0 +1,121 @@
+//go:build linux
+
+package server
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yurikilian/bills/internal/logger"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const upgradeChildEnv = "SERVER_TEST_UPGRADE_CHILD"
+
+func newPidServer(options *Options) *RestServer {
+	return NewRestServer(options).Router(NewRestRouter().Get("/", func(ctx IHttpContext) error {
+		return ctx.WriteResponse(http.StatusOK, os.Getpid())
+	}))
+}
+
+// withAttachedPidServer attaches a pid server on the socket next to the
+// server one, as done for admin servers.
+func withAttachedPidServer(server *RestServer, socket string) *RestServer {
+	attached := newPidServer(NewRestServerOptions("127.0.0.1:0", logger.NewProvider().ProvideLog()))
+	return server.AttachListener(ListenerOptions{Network: "unix", Address: socket + ".admin"}, attached.Serve, attached.Shutdown)
+}
+
+func unixClient(socket string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+}
+
+func getPid(t *testing.T, client *http.Client, url string) int {
+	res, err := client.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(body)))
+	require.NoError(t, err)
+	return pid
+}
+
+// TestRestServer_Upgrade_Child is the new process started by TestRestServer_Upgrade.
+func TestRestServer_Upgrade_Child(t *testing.T) {
+	if len(os.Getenv(upgradeChildEnv)) == 0 {
+		t.Skip("only runs as the upgraded process")
+	}
+	require.True(t, IsUpgradeChild())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	options := NewRestServerOptions("127.0.0.1:0", logger.NewProvider().ProvideLog()).
+		WithListener(ListenerOptions{Network: "unix", Address: os.Getenv(upgradeChildEnv)})
+	withAttachedPidServer(newPidServer(options), os.Getenv(upgradeChildEnv)).Start(ctx)
+}
+
+func TestRestServer_Upgrade(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bills.sock")
+	t.Setenv(upgradeChildEnv, socket)
+
+	options := NewRestServerOptions("127.0.0.1:0", logger.NewProvider().ProvideLog()).
+		WithListener(ListenerOptions{Network: "unix", Address: socket}).
+		WithUpgrade(&UpgradeOptions{
+			Executable:   os.Args[0],
+			Args:         []string{"-test.run=^TestRestServer_Upgrade_Child$"},
+			ReadyTimeout: 5 * time.Second,
+		})
+	server := withAttachedPidServer(newPidServer(options), socket)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		server.Start(context.Background())
+	}()
+	require.Eventually(t, server.IsReady, time.Second, time.Millisecond)
+
+	url := "http://" + server.Addrs()[0].String()
+	assert.Equal(t, os.Getpid(), getPid(t, http.DefaultClient, url))
+	assert.Equal(t, os.Getpid(), getPid(t, unixClient(socket+".admin"), "http://unix/"))
+
+	require.NoError(t, server.Upgrade(context.Background()))
+	require.NoError(t, server.Shutdown(context.Background()))
+	<-stopped
+
+	http.DefaultClient.CloseIdleConnections()
+	childPid := getPid(t, http.DefaultClient, url)
+	assert.NotEqual(t, os.Getpid(), childPid)
+	assert.Equal(t, childPid, getPid(t, unixClient(socket), "http://unix/"))
+	assert.Equal(t, childPid, getPid(t, unixClient(socket+".admin"), "http://unix/"))
+}
+
+func TestRestServer_Upgrade_ChildFails(t *testing.T) {
+	options := NewRestServerOptions("127.0.0.1:0", logger.NewProvider().ProvideLog()).
+		WithUpgrade(&UpgradeOptions{Executable: "/bin/false", ReadyTimeout: time.Second})
+	server := newPidServer(options)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Start(ctx)
+	require.Eventually(t, server.IsReady, time.Second, time.Millisecond)
+
+	err := server.Upgrade(context.Background())
+
+	assert.ErrorContains(t, err, "exited before it was ready")
+	assert.True(t, server.IsReady())
+	assert.Equal(t, os.Getpid(), getPid(t, http.DefaultClient, "http://"+server.Addrs()[0].String()))
+}
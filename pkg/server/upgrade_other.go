@@ -0,0 +1,26 @@
+//go:build !linux
+
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+)
+
+func defaultUpgradeSignals() []os.Signal {
+	return nil
+}
+
+// Upgrade is not available on this platform.
+func (srv *RestServer) Upgrade(ctx context.Context) error {
+	return ErrUpgradeUnsupported
+}
+
+func inheritedListeners() ([]net.Listener, error) {
+	return nil, nil
+}
+
+func notifyUpgradeParent() error {
+	return nil
+}
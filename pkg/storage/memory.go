@@ -1,22 +1,135 @@
 package storage
 
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
 type InMemoryStorage[T any] struct {
+	mu        sync.RWMutex
 	nextFloat float64
 	memory    map[float64]*T
 }
 
-func (r *InMemoryStorage[T]) Find(id float64) (*T, error) {
+func (r *InMemoryStorage[T]) Find(_ context.Context, id float64) (*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	return r.memory[id], nil
 }
 
-func (r *InMemoryStorage[T]) Create(entity *T) (*T, error) {
+func (r *InMemoryStorage[T]) FindBy(_ context.Context, column string, value any) (*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, entity := range r.memory {
+		val := reflect.ValueOf(entity).Elem()
+		if val.Kind() != reflect.Struct {
+			continue
+		}
+		for i := 0; i < val.NumField(); i++ {
+			if columnName(val.Type().Field(i)) != column {
+				continue
+			}
+			if reflect.DeepEqual(val.Field(i).Interface(), value) {
+				return entity, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *InMemoryStorage[T]) Create(_ context.Context, entity *T) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	r.nextFloat++
 	r.memory[r.nextFloat] = entity
+	setIdIfEmpty(entity, r.nextFloat)
+
+	return entity, nil
+}
+
+func (r *InMemoryStorage[T]) Update(_ context.Context, id float64, entity *T) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.memory[id]; !ok {
+		return nil, fmt.Errorf("entity %v not found", id)
+	}
+	r.memory[id] = entity
 
 	return entity, nil
 }
 
+func (r *InMemoryStorage[T]) UpdateColumn(_ context.Context, id float64, column string, value any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entity, ok := r.memory[id]
+	if !ok {
+		return fmt.Errorf("entity %v not found", id)
+	}
+
+	// The stored entity may be held by readers, the column is set on a copy.
+	updated := *entity
+	val := reflect.ValueOf(&updated).Elem()
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("unknown column %v", column)
+	}
+	for i := 0; i < val.NumField(); i++ {
+		if columnName(val.Type().Field(i)) != column {
+			continue
+		}
+		field := val.Field(i)
+		newValue := reflect.ValueOf(value)
+		if !newValue.CanConvert(field.Type()) {
+			return fmt.Errorf("cannot set column %v to %T", column, value)
+		}
+		field.Set(newValue.Convert(field.Type()))
+		r.memory[id] = &updated
+		return nil
+	}
+	return fmt.Errorf("unknown column %v", column)
+}
+
+func (r *InMemoryStorage[T]) List(_ context.Context, offset int, limit int) ([]*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]float64, 0, len(r.memory))
+	for id := range r.memory {
+		ids = append(ids, id)
+	}
+	sort.Float64s(ids)
+
+	entities := make([]*T, 0, limit)
+	for i := offset; i < len(ids) && len(entities) < limit; i++ {
+		entities = append(entities, r.memory[ids[i]])
+	}
+
+	return entities, nil
+}
+
+func setIdIfEmpty(entity any, id float64) {
+	val := reflect.ValueOf(entity).Elem()
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		if columnName(val.Type().Field(i)) == "id" && field.Kind() == reflect.Float64 && field.CanSet() && field.Float() == 0 {
+			field.SetFloat(id)
+			return
+		}
+	}
+}
+
 func NewInMemoryStorage[T any]() *InMemoryStorage[T] {
 	return &InMemoryStorage[T]{
 		nextFloat: 0,
@@ -16,10 +16,10 @@ type PsqlStorage[T any] struct {
 	tableName *string
 }
 
-func (s *PsqlStorage[T]) Find(id float64) (*T, error) {
+func (s *PsqlStorage[T]) Find(ctx context.Context, id float64) (*T, error) {
 	var t *T
 
-	rows, err := s.db.Query(fmt.Sprintf("SELECT * FROM %v WHERE id = $1", *s.tableName), id)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %v WHERE id = $1", *s.tableName), id)
 
 	if err != nil {
 		return nil, err
@@ -41,7 +41,41 @@ func (s *PsqlStorage[T]) Find(id float64) (*T, error) {
 	return t, nil
 }
 
-func (s *PsqlStorage[T]) Create(entity *T) (*T, error) {
+func (s *PsqlStorage[T]) FindBy(ctx context.Context, column string, value any) (*T, error) {
+	if !s.hasColumn(column) {
+		return nil, fmt.Errorf("unknown column %v", column)
+	}
+
+	var t *T
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %v WHERE %v = $1", *s.tableName, column), value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			logger.Log.Warn(context.Background(), err.Error())
+		}
+	}(rows)
+
+	m := FirstRowToMap(rows)
+	if len(*m) == 0 {
+		return nil, nil
+	}
+
+	err = mapstructure.WeakDecode(m, &t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Create inserts the entity. A zero id is left to the column default and the
+// generated one is set on the entity.
+func (s *PsqlStorage[T]) Create(ctx context.Context, entity *T) (*T, error) {
 
 	val := reflect.ValueOf(entity).Elem()
 
@@ -53,34 +87,132 @@ func (s *PsqlStorage[T]) Create(entity *T) (*T, error) {
 	values := make([]any, 0, n)
 
 	for i := 0; i < val.NumField(); i++ {
-		var columnName string
-		if columnTag, ok := val.Type().Field(i).Tag.Lookup("column"); ok {
-			columnName = columnTag
-		} else {
-			columnName = val.Type().Field(i).Name
-		}
+		columnName := columnName(val.Type().Field(i))
 
 		value := val.Field(i).Interface()
+		if columnName == "id" && val.Field(i).IsZero() {
+			continue
+		}
 		values = append(values, value)
 
-		if i > 0 {
+		if len(values) > 1 {
 			columnsSb.WriteString(", ")
 			valuesSb.WriteString(", ")
 		}
 		columnsSb.WriteString(columnName)
-		valuesSb.WriteString(fmt.Sprint("$", i+1))
+		valuesSb.WriteString(fmt.Sprint("$", len(values)))
 
 	}
 
-	query := fmt.Sprintf("INSERT INTO %v(%v) VALUES(%v)", *s.tableName, columnsSb.String(), valuesSb.String())
-	_, err := s.db.Exec(query, values...)
+	query := fmt.Sprintf("INSERT INTO %v(%v) VALUES(%v) RETURNING id", *s.tableName, columnsSb.String(), valuesSb.String())
 
-	if err != nil {
+	var id float64
+	if err := s.db.QueryRowContext(ctx, query, values...).Scan(&id); err != nil {
 		return nil, fmt.Errorf("could not insert row on database: %w", err)
 	}
+	setIdIfEmpty(entity, id)
 	return entity, nil
 }
 
+func (s *PsqlStorage[T]) Update(ctx context.Context, id float64, entity *T) (*T, error) {
+
+	val := reflect.ValueOf(entity).Elem()
+
+	var setSb strings.Builder
+
+	values := make([]any, 0, val.NumField()+1)
+
+	for i := 0; i < val.NumField(); i++ {
+		columnName := columnName(val.Type().Field(i))
+		if columnName == "id" {
+			continue
+		}
+
+		values = append(values, val.Field(i).Interface())
+
+		if setSb.Len() > 0 {
+			setSb.WriteString(", ")
+		}
+		setSb.WriteString(fmt.Sprint(columnName, " = $", len(values)))
+	}
+
+	values = append(values, id)
+
+	query := fmt.Sprintf("UPDATE %v SET %v WHERE id = $%v", *s.tableName, setSb.String(), len(values))
+	result, err := s.db.ExecContext(ctx, query, values...)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not update row on database: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil, fmt.Errorf("entity %v not found", id)
+	}
+	return entity, nil
+}
+
+func (s *PsqlStorage[T]) UpdateColumn(ctx context.Context, id float64, column string, value any) error {
+	if !s.hasColumn(column) || column == "id" {
+		return fmt.Errorf("unknown column %v", column)
+	}
+
+	query := fmt.Sprintf("UPDATE %v SET %v = $1 WHERE id = $2", *s.tableName, column)
+	result, err := s.db.ExecContext(ctx, query, value, id)
+	if err != nil {
+		return fmt.Errorf("could not update row on database: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("entity %v not found", id)
+	}
+	return nil
+}
+
+func (s *PsqlStorage[T]) List(ctx context.Context, offset int, limit int) ([]*T, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %v ORDER BY id LIMIT $1 OFFSET $2", *s.tableName), limit, offset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			logger.Log.Warn(context.Background(), err.Error())
+		}
+	}(rows)
+
+	entities := make([]*T, 0, limit)
+	for {
+		m := FirstRowToMap(rows)
+		if len(*m) == 0 {
+			break
+		}
+
+		var t *T
+		if err = mapstructure.WeakDecode(m, &t); err != nil {
+			return nil, err
+		}
+		entities = append(entities, t)
+	}
+	return entities, rows.Err()
+}
+
+func (s *PsqlStorage[T]) hasColumn(column string) bool {
+	var t T
+	val := reflect.ValueOf(&t).Elem()
+	if val.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		if columnName(val.Type().Field(i)) == column {
+			return true
+		}
+	}
+	return false
+}
+
 func FirstRowToMap(rows *sql.Rows) *map[string]interface{} {
 
 	cols, _ := rows.Columns()
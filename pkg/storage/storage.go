@@ -1,6 +1,25 @@
 package storage
 
+import (
+	"context"
+	"reflect"
+)
+
 type Storage[T any] interface {
-	Find(id float64) (*T, error)
-	Create(*T) (*T, error)
+	Find(ctx context.Context, id float64) (*T, error)
+	FindBy(ctx context.Context, column string, value any) (*T, error)
+	Create(ctx context.Context, entity *T) (*T, error)
+	Update(ctx context.Context, id float64, entity *T) (*T, error)
+	// UpdateColumn sets a single column of the entity, leaving the others as
+	// they are in the storage rather than as a caller last read them.
+	UpdateColumn(ctx context.Context, id float64, column string, value any) error
+	// List returns at most limit entities ordered by id, skipping the first offset.
+	List(ctx context.Context, offset int, limit int) ([]*T, error)
+}
+
+func columnName(field reflect.StructField) string {
+	if columnTag, ok := field.Tag.Lookup("column"); ok {
+		return columnTag
+	}
+	return field.Name
 }
@@ -2,6 +2,7 @@ package telemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/otel"
@@ -36,12 +37,12 @@ func (p *provider) configure() {
 		panic(err)
 	}
 
-	p.Close = func() {
-		if err := shutdownTracer(p.ctx); err != nil {
-			panic(err)
-		}
+	p.closeFunc = func(ctx context.Context) error {
+		return errors.Join(shutdownTracer(ctx), shutdownMeterProvider(ctx))
+	}
 
-		if err := shutdownMeterProvider(p.ctx); err != nil {
+	p.Close = func() {
+		if err := p.closeFunc(p.ctx); err != nil {
 			panic(err)
 		}
 	}
@@ -105,3 +106,14 @@ func Init(ctx context.Context) func() {
 	telemetryProvider.configure()
 	return telemetryProvider.Close
 }
+
+// InitWithShutdown configures telemetry and returns a shutdown function that
+// flushes pending spans and metrics, reporting failures instead of panicking.
+func InitWithShutdown(ctx context.Context) func(context.Context) error {
+	telemetryProvider := provider{
+		ctx: ctx,
+	}
+
+	telemetryProvider.configure()
+	return telemetryProvider.closeFunc
+}